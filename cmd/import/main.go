@@ -0,0 +1,70 @@
+// Command import loads products from a CSV or XLSX file into the database configured
+// in .env, with the same validation and upsert rules as POST /api/products/import.
+//
+//	go run ./cmd/import -file products.xlsx -mapping "barcode=EAN,price=Cost" -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/importer"
+)
+
+func main() {
+	path := flag.String("file", "", "the CSV or XLSX file to import")
+	mappingFlag := flag.String("mapping", "", "column mapping as field=Column,field=Column")
+	dryRun := flag.Bool("dry-run", false, "validate the rows without writing anything")
+	userID := flag.String("user", "import", "the user id recorded in the transaction ledger")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mapping, err := importer.ParseMapping(*mappingFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	table, err := importer.Read(file, importer.DetectFormat(*path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := table.Products(mapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	summary, err := helper.ImportProducts(ctx, *userID, rows, *dryRun)
+	for _, row := range summary.Rows {
+		fmt.Printf("line %d: %s %s\n", row.Row, row.Action, row.Barcode)
+		for _, problem := range row.Errors {
+			fmt.Printf("    %s\n", problem)
+		}
+	}
+	fmt.Printf("%d rows: %d added, %d updated, %d failed (dry run: %v)\n",
+		summary.Total, summary.Added, summary.Updated, summary.Failed, summary.DryRun)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	if summary.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -56,3 +56,18 @@ func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collecti
 	var collection *mongo.Collection = client.Database("STOCKET").Collection(collectionName)
 	return collection
 }
+
+// WithTransaction runs fn inside a multi-document transaction that is committed when fn
+// returns nil and aborted otherwise. It needs MongoDB to run as a replica set.
+func WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
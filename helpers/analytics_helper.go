@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/analytics"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultAnalyticsBuckets is the number of buckets in a series when the request does not
+// say where it starts.
+const DefaultAnalyticsBuckets = 30
+
+// MovementFilter narrows the movements of a series to a product, a category or the user
+// who made them. Empty fields do not filter.
+type MovementFilter struct {
+	ProductID string
+	Category  *int
+	UserID    string
+}
+
+// MovementSeries is the stock movements over a range in buckets of an interval.
+type MovementSeries struct {
+	Interval analytics.Interval `json:"interval"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Buckets  []analytics.Bucket `json:"buckets"`
+}
+
+// MovementAnalytics sums the inbound and outbound quantities and counts the movements in
+// the ledger from from through to, grouped with $dateTrunc in UTC. Every bucket of the
+// range is returned, empty ones included.
+func MovementAnalytics(ctx context.Context, filter MovementFilter, interval analytics.Interval, from time.Time, to time.Time) (MovementSeries, error) {
+	series := MovementSeries{Interval: interval, From: from, To: to}
+
+	// Check the range before querying so that an oversized one is refused cheaply.
+	if _, err := analytics.Fill(nil, from, to, interval); err != nil {
+		return series, err
+	}
+
+	match := bson.M{
+		"processtime": bson.M{"$gte": from, "$lte": to},
+		"direction":   bson.M{"$in": []string{string(types.Inbound), string(types.Outbound)}},
+	}
+	if filter.UserID != "" {
+		match["userid"] = filter.UserID
+	}
+	if filter.Category != nil {
+		ids, err := productIDsInCategory(ctx, *filter.Category)
+		if err != nil {
+			return series, err
+		}
+		match["productid"] = bson.M{"$in": ids}
+	}
+	if filter.ProductID != "" {
+		if filter.Category != nil {
+			match["$and"] = bson.A{bson.M{"productid": filter.ProductID}}
+		} else {
+			match["productid"] = filter.ProductID
+		}
+	}
+
+	trunc := bson.D{
+		{Key: "date", Value: "$processtime"},
+		{Key: "unit", Value: string(interval)},
+		{Key: "timezone", Value: "UTC"},
+	}
+	if interval == analytics.Week {
+		trunc = append(trunc, bson.E{Key: "startOfWeek", Value: "monday"})
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$dateTrunc", Value: trunc}}},
+			{Key: "inbound", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$direction", string(types.Inbound)}}}, "$amount", 0,
+			}}}}}},
+			{Key: "outbound", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$direction", string(types.Outbound)}}}, "$amount", 0,
+			}}}}}},
+			{Key: "transactions", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := transactionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return series, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Start        time.Time `bson:"_id"`
+		Inbound      int64     `bson:"inbound"`
+		Outbound     int64     `bson:"outbound"`
+		Transactions int64     `bson:"transactions"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return series, err
+	}
+
+	buckets := make([]analytics.Bucket, 0, len(results))
+	for _, result := range results {
+		buckets = append(buckets, analytics.Bucket{
+			Start:        result.Start,
+			Inbound:      uint(result.Inbound),
+			Outbound:     uint(result.Outbound),
+			Transactions: result.Transactions,
+		})
+	}
+	series.Buckets, err = analytics.Fill(buckets, from, to, interval)
+	return series, err
+}
+
+func productIDsInCategory(ctx context.Context, category int) ([]string, error) {
+	cursor, err := productCollection.Find(ctx, bson.M{"category": category}, options.Find().SetProjection(bson.M{"productid": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ProductID)
+	}
+	return ids, nil
+}
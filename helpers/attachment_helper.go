@@ -0,0 +1,259 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/storage"
+	"github.com/Deatsilence/go-stocket/pkg/thumbnail"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ATTACHMENT_DIR is the directory uploaded files are kept in.
+var ATTACHMENT_DIR string = attachmentDir()
+
+// AttachmentStorage keeps the uploaded files.
+var AttachmentStorage storage.Storage = openLocalStorage(ATTACHMENT_DIR)
+
+const (
+	// MaxAttachmentSize is the largest file that can be uploaded, in bytes.
+	MaxAttachmentSize = 10 << 20
+	// MaxAttachments is the largest number of files a product can have.
+	MaxAttachments = 20
+)
+
+var (
+	ErrAttachmentTooLarge = fmt.Errorf("files can be at most %d MB", MaxAttachmentSize>>20)
+	ErrAttachmentType     = errors.New("only images, PDF, text, CSV and Office files can be uploaded")
+	ErrTooManyAttachments = fmt.Errorf("a product can have at most %d files", MaxAttachments)
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrInvalidImage       = errors.New("the image cannot be read")
+)
+
+// attachmentTypes are the sniffed content types that may be uploaded. HTML and other
+// types a browser could run are left out.
+var attachmentTypes = map[string]string{
+	"image/jpeg":                "image",
+	"image/png":                 "image",
+	"image/gif":                 "image",
+	"image/webp":                "image",
+	"application/pdf":           "document",
+	"text/plain; charset=utf-8": "document",
+	"application/zip":           "document",
+}
+
+// documentTypes name files by their extension where sniffing only sees plain text or a zip.
+var documentTypes = map[string]string{
+	".csv":  "text/csv",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+func attachmentDir() string {
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	return dir
+}
+
+func openLocalStorage(dir string) storage.Storage {
+	local, err := storage.NewLocal(dir)
+	if err != nil {
+		log.Printf("Error while opening attachment storage %v: %v", dir, err)
+		return &storage.Local{Root: dir}
+	}
+	return local
+}
+
+// AttachmentURL is the path an attachment, or its thumbnail, is downloaded from.
+func AttachmentURL(productID string, attachmentID string, thumbnail bool) string {
+	url := "/api/products/" + productID + "/attachments/" + attachmentID
+	if thumbnail {
+		url += "/thumbnail"
+	}
+	return url
+}
+
+// SaveAttachment stores an uploaded file for a product and adds it to the product. A
+// thumbnail is made for images.
+func SaveAttachment(ctx context.Context, productID string, userID string, fileHeader *multipart.FileHeader) (models.Attachment, error) {
+	var attachment models.Attachment
+	if fileHeader.Size > MaxAttachmentSize {
+		return attachment, ErrAttachmentTooLarge
+	}
+
+	var product models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID}, options.FindOne().SetProjection(bson.M{"attachments": 1, "status": 1})).Decode(&product)
+	if err != nil {
+		return attachment, err
+	}
+	if ProductStatus(product) == types.ProductArchived {
+		return attachment, ErrProductArchived
+	}
+	if len(product.Attachments) >= MaxAttachments {
+		return attachment, ErrTooManyAttachments
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return attachment, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxAttachmentSize+1))
+	if err != nil {
+		return attachment, err
+	}
+	if len(data) > MaxAttachmentSize {
+		return attachment, ErrAttachmentTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	kind, ok := attachmentTypes[contentType]
+	if !ok {
+		return attachment, ErrAttachmentType
+	}
+	name := attachmentName(fileHeader.Filename)
+	if specific, ok := documentTypes[strings.ToLower(filepath.Ext(name))]; ok && kind == "document" {
+		contentType = specific
+	}
+
+	id := primitive.NewObjectID().Hex()
+	prefix := "products/" + productID + "/" + id + "/"
+	attachment = models.Attachment{
+		AttachmentID: id,
+		Kind:         kind,
+		Name:         name,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		URL:          AttachmentURL(productID, id, false),
+		Key:          prefix + "file",
+		UploadedBy:   userID,
+	}
+	attachment.UploadedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	var thumb bytes.Buffer
+	if kind == "image" {
+		img, format, err := thumbnail.Make(bytes.NewReader(data), thumbnail.DefaultSize)
+		if err != nil {
+			return attachment, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if _, err := thumbnail.Encode(&thumb, img, format); err != nil {
+			return attachment, err
+		}
+		attachment.ThumbnailKey = prefix + "thumbnail"
+		attachment.ThumbnailURL = AttachmentURL(productID, id, true)
+	}
+
+	if err := AttachmentStorage.Save(ctx, attachment.Key, bytes.NewReader(data)); err != nil {
+		return attachment, err
+	}
+	if attachment.ThumbnailKey != "" {
+		if err := AttachmentStorage.Save(ctx, attachment.ThumbnailKey, &thumb); err != nil {
+			deleteAttachmentFiles(ctx, attachment)
+			return attachment, err
+		}
+	}
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	result, err := productCollection.UpdateOne(ctx,
+		bson.M{"productid": productID},
+		bson.M{"$push": bson.M{"attachments": attachment}, "$set": bson.M{"updatedat": updatedAt}},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		err = errors.New("product not found")
+	}
+	if err != nil {
+		deleteAttachmentFiles(ctx, attachment)
+		return attachment, err
+	}
+	return attachment, nil
+}
+
+// attachmentName keeps the base name of an uploaded file without control characters.
+func attachmentName(filename string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[len(runes)-100:])
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	return name
+}
+
+// FindAttachment looks up an attachment of a product.
+func FindAttachment(ctx context.Context, productID string, attachmentID string) (models.Attachment, error) {
+	var product models.Product
+	err := productCollection.FindOne(ctx,
+		bson.M{"productid": productID, "attachments.attachmentid": attachmentID},
+		options.FindOne().SetProjection(bson.M{"attachments.$": 1}),
+	).Decode(&product)
+	if err != nil || len(product.Attachments) == 0 {
+		return models.Attachment{}, ErrAttachmentNotFound
+	}
+	return product.Attachments[0], nil
+}
+
+// DeleteAttachment removes an attachment from a product and deletes its files.
+func DeleteAttachment(ctx context.Context, productID string, attachmentID string) error {
+	attachment, err := FindAttachment(ctx, productID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := CheckProductsWritable(ctx, productID); err != nil {
+		return err
+	}
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	_, err = productCollection.UpdateOne(ctx,
+		bson.M{"productid": productID},
+		bson.M{"$pull": bson.M{"attachments": bson.M{"attachmentid": attachmentID}}, "$set": bson.M{"updatedat": updatedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	deleteAttachmentFiles(ctx, attachment)
+	return nil
+}
+
+// DeleteProductFiles deletes the files of every attachment of a deleted product.
+func DeleteProductFiles(ctx context.Context, product models.Product) {
+	for _, attachment := range product.Attachments {
+		deleteAttachmentFiles(ctx, attachment)
+	}
+}
+
+func deleteAttachmentFiles(ctx context.Context, attachment models.Attachment) {
+	for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := AttachmentStorage.Delete(ctx, key); err != nil {
+			log.Printf("Error while deleting file %v: %v", key, err)
+		}
+	}
+}
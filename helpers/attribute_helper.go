@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/attributes"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var attributeSchemaCollection *mongo.Collection = database.OpenCollection(database.Client, "attributeschema")
+
+// attributeParameter starts the product list parameters that filter by an attribute, as
+// in attr.voltage=230 or attr.warranty.min=12.
+const attributeParameter = "attr."
+
+// FindAttributeSchema returns the attribute schema of a category. A category without a
+// schema has no attributes.
+func FindAttributeSchema(ctx context.Context, category int) (models.AttributeSchema, error) {
+	schema := models.AttributeSchema{Category: category}
+	err := attributeSchemaCollection.FindOne(ctx, bson.M{"category": category}).Decode(&schema)
+	if err == mongo.ErrNoDocuments {
+		return models.AttributeSchema{Category: category}, nil
+	}
+	return schema, err
+}
+
+// ValidateProductAttributes checks the attributes of a product against the schema of its
+// category and stores them normalized on the product. Products saved before a schema
+// changed are checked again the next time they are updated.
+func ValidateProductAttributes(ctx context.Context, product *models.Product) error {
+	if product.Category == nil {
+		return nil
+	}
+	schema, err := FindAttributeSchema(ctx, *product.Category)
+	if err != nil {
+		return err
+	}
+	values, err := attributes.Validate(schema.Attributes, product.Attributes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", types.CategoryTypes(*product.Category), err)
+	}
+	product.Attributes = values
+	return nil
+}
+
+// ValidatePartialAttributes checks the attributes of a partial update, taking the
+// category or the attributes the update leaves out from the stored product.
+func ValidatePartialAttributes(ctx context.Context, productID string, product *models.Product) error {
+	var stored models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID},
+		options.FindOne().SetProjection(bson.M{"category": 1, "attributes": 1}),
+	).Decode(&stored)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	merged := models.Product{Category: product.Category, Attributes: product.Attributes}
+	if merged.Category == nil {
+		merged.Category = stored.Category
+	}
+	if merged.Attributes == nil {
+		merged.Attributes = stored.Attributes
+	}
+	if err := ValidateProductAttributes(ctx, &merged); err != nil {
+		return err
+	}
+	if merged.Attributes == nil {
+		merged.Attributes = map[string]interface{}{}
+	}
+	product.Attributes = merged.Attributes
+	return nil
+}
+
+// attributeFilters builds the filters of the attr. parameters of the product list.
+func attributeFilters(query url.Values) ([]bson.D, error) {
+	keys := make([]string, 0)
+	for key := range query {
+		if strings.HasPrefix(key, attributeParameter) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []bson.D
+	ranges := map[string]bool{}
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, attributeParameter)
+		if base := strings.TrimSuffix(strings.TrimSuffix(name, ".min"), ".max"); base != name {
+			if ranges[base] {
+				continue
+			}
+			ranges[base] = true
+			filter, err := attributes.Range(base, query.Get(attributeParameter+base+".min"), query.Get(attributeParameter+base+".max"))
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+		filter, err := attributes.Match(name, query.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
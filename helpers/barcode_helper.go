@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/barcode"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var counterCollection *mongo.Collection = database.OpenCollection(database.Client, "counter")
+
+var BARCODE_PREFIX string = barcodePrefix()
+
+func barcodePrefix() string {
+	prefix := os.Getenv("BARCODE_PREFIX")
+	if prefix == "" {
+		prefix = "200"
+	}
+	return prefix
+}
+
+// GenerateInternalBarcode returns an unused EAN-13 barcode built from BARCODE_PREFIX and
+// a sequence kept in the counter collection.
+func GenerateInternalBarcode(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		var counter struct {
+			Seq uint64 `bson:"seq"`
+		}
+		err := counterCollection.FindOneAndUpdate(ctx,
+			bson.M{"_id": "barcode"},
+			bson.M{"$inc": bson.M{"seq": 1}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&counter)
+		if err != nil {
+			return "", err
+		}
+
+		code, err := barcode.Internal(BARCODE_PREFIX, counter.Seq)
+		if err != nil {
+			return "", err
+		}
+
+		// Codes may already be taken by products that were entered by hand with the prefix.
+		inUse, err := BarcodeInUse(ctx, code, "")
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return code, nil
+		}
+	}
+	return "", errors.New("could not find a free internal barcode")
+}
+
+// PRODUCT_LINK_BASE is the public URL product links start with, such as
+// https://stock.example.com/api/products/. It is configured rather than taken from the
+// request, as the host a request arrives on may be internal or set by the client.
+var PRODUCT_LINK_BASE string = os.Getenv("PRODUCT_LINK_BASE")
+
+var ErrNoProductLinkBase = errors.New("PRODUCT_LINK_BASE is not configured, QR codes need the public URL of the products")
+
+// ProductLink is the deep link encoded in product QR codes.
+func ProductLink(productID string) (string, error) {
+	if PRODUCT_LINK_BASE == "" {
+		return "", ErrNoProductLinkBase
+	}
+	return strings.TrimRight(PRODUCT_LINK_BASE, "/") + "/" + productID, nil
+}
@@ -0,0 +1,360 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxBatchSize is the largest number of products one batch update may change.
+const MaxBatchSize = 1000
+
+var (
+	ErrStockChanged     = errors.New("the stock changed while the batch was applied")
+	ErrBatchTooLarge    = fmt.Errorf("at most %d products can be changed at once", MaxBatchSize)
+	ErrEmptyBatchFilter = errors.New("the filter must select products by productids, category or location")
+)
+
+// BatchResult is the outcome of a batch update for one product.
+type BatchResult struct {
+	ProductID string       `json:"productid"`
+	Status    string       `json:"status"` /// updated, unchanged, failed or rolledback
+	Error     string       `json:"error,omitempty"`
+	OldPrice  *types.Money `json:"oldprice,omitempty"`
+	NewPrice  *types.Money `json:"newprice,omitempty"`
+	OldStock  uint         `json:"oldstock"`
+	NewStock  uint         `json:"newstock"`
+}
+
+// BatchSummary is the outcome of a whole batch update. Ledger entries written by the
+// batch carry its id.
+type BatchSummary struct {
+	BatchID   string        `json:"batchid"`
+	Atomic    bool          `json:"atomic"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+func (s *BatchSummary) add(result BatchResult) {
+	switch result.Status {
+	case "updated":
+		s.Updated++
+	case "unchanged", "rolledback":
+		s.Unchanged++
+	default:
+		s.Failed++
+	}
+	s.Results = append(s.Results, result)
+}
+
+// batchTarget is a product with the absolute values it should end up with.
+type batchTarget struct {
+	product models.Product
+	patch   models.BatchPatch
+	err     error
+}
+
+// BatchUpdateProducts applies a batch of patches, or one operation to every product
+// matching a filter. In atomic mode the first failure rolls the whole batch back and is
+// returned along with the summary; otherwise every product is applied on its own and
+// failures are only reported in the summary.
+func BatchUpdateProducts(ctx context.Context, userID string, request models.BatchUpdate) (BatchSummary, error) {
+	summary := BatchSummary{BatchID: primitive.NewObjectID().Hex(), Atomic: request.Atomic, Results: []BatchResult{}}
+
+	targets, err := batchTargets(ctx, request)
+	if err != nil {
+		return summary, err
+	}
+
+	if !request.Atomic {
+		for _, target := range targets {
+			summary.add(applyBatchTarget(ctx, userID, summary.BatchID, target, nil, nil))
+		}
+		return summary, nil
+	}
+
+	var results []BatchResult
+	err = database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		// The callback may run again when the transaction is retried.
+		results = results[:0]
+		var ledger, priceChanges []interface{}
+
+		for _, target := range targets {
+			result := applyBatchTarget(sessCtx, userID, summary.BatchID, target, &ledger, &priceChanges)
+			results = append(results, result)
+			if result.Status == "failed" {
+				return fmt.Errorf("product %v: %v", result.ProductID, result.Error)
+			}
+		}
+
+		if len(ledger) > 0 {
+			if _, err := transactionCollection.InsertMany(sessCtx, ledger); err != nil {
+				return err
+			}
+		}
+		if len(priceChanges) > 0 {
+			if _, err := priceChangeCollection.InsertMany(sessCtx, priceChanges); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, result := range results {
+		if err != nil && result.Status == "updated" {
+			result.Status = "rolledback"
+		}
+		summary.add(result)
+	}
+	return summary, err
+}
+
+// batchTargets loads the products of a batch and works out the values each should get.
+func batchTargets(ctx context.Context, request models.BatchUpdate) ([]batchTarget, error) {
+	if len(request.Patches) > 0 {
+		ids := make([]string, 0, len(request.Patches))
+		for _, patch := range request.Patches {
+			ids = append(ids, patch.ProductID)
+		}
+		products, err := findBatchProducts(ctx, bson.M{"productid": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, err
+		}
+
+		byID := map[string]models.Product{}
+		for _, product := range products {
+			byID[product.ProductID] = product
+		}
+
+		seen := map[string]bool{}
+		targets := make([]batchTarget, 0, len(request.Patches))
+		for _, patch := range request.Patches {
+			product, ok := byID[patch.ProductID]
+			target := batchTarget{product: product, patch: patch}
+			if seen[patch.ProductID] {
+				target.err = errors.New("product is patched more than once")
+			} else if !ok {
+				target.product.ProductID = patch.ProductID
+				target.err = errors.New("product not found")
+			} else if patch.Price != nil {
+				target.err = NormalizePrice(patch.Price)
+			}
+			seen[patch.ProductID] = true
+			targets = append(targets, target)
+		}
+		return targets, nil
+	}
+
+	filter := bson.M{}
+	if len(request.Filter.ProductIDs) > 0 {
+		filter["productid"] = bson.M{"$in": request.Filter.ProductIDs}
+	}
+	if request.Filter.Category != nil {
+		filter["category"] = int(*request.Filter.Category)
+	}
+	if request.Filter.Location != nil {
+		filter["location"] = *request.Filter.Location
+	}
+	if len(filter) == 0 {
+		return nil, ErrEmptyBatchFilter
+	}
+
+	products, err := findBatchProducts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]batchTarget, 0, len(products))
+	for _, product := range products {
+		patch, err := BatchOperationPatch(product, *request.Operation)
+		targets = append(targets, batchTarget{product: product, patch: patch, err: err})
+	}
+	return targets, nil
+}
+
+func findBatchProducts(ctx context.Context, filter bson.M) ([]models.Product, error) {
+	count, err := productCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxBatchSize {
+		return nil, fmt.Errorf("the batch matches %d products: %w", count, ErrBatchTooLarge)
+	}
+
+	cursor, err := productCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	err = cursor.All(ctx, &products)
+	return products, err
+}
+
+// BatchOperationPatch works out the price or stock an operation gives a product.
+func BatchOperationPatch(product models.Product, operation models.BatchOperation) (models.BatchPatch, error) {
+	patch := models.BatchPatch{ProductID: product.ProductID}
+
+	if operation.Field == "stock" {
+		switch operation.Op {
+		case "set":
+			stock, err := strconv.ParseUint(operation.Value.String(), 10, 32)
+			if err != nil {
+				return patch, fmt.Errorf("invalid stock %v", operation.Value)
+			}
+			value := uint(stock)
+			patch.Stock = &value
+		case "add":
+			delta, err := strconv.ParseInt(operation.Value.String(), 10, 32)
+			if err != nil {
+				return patch, fmt.Errorf("invalid stock change %v", operation.Value)
+			}
+			if int64(product.Stock)+delta < 0 {
+				return patch, ErrInsufficientStock
+			}
+			value := uint(int64(product.Stock) + delta)
+			patch.Stock = &value
+		default:
+			return patch, errors.New("stock can only be set or added to")
+		}
+		return patch, nil
+	}
+
+	if operation.Op == "set" {
+		currency := operation.Currency
+		if currency == "" && product.Price != nil {
+			currency = product.Price.Currency
+		}
+		price, err := types.NewMoney(operation.Value.String(), currency)
+		if err != nil {
+			return patch, err
+		}
+		patch.Price = &price
+		return patch, NormalizePrice(patch.Price)
+	}
+
+	if product.Price == nil {
+		return patch, errors.New("product has no price")
+	}
+	value, ok := new(big.Rat).SetString(operation.Value.String())
+	if !ok {
+		return patch, fmt.Errorf("invalid amount %v", operation.Value)
+	}
+
+	current := product.Price.Rat()
+	switch operation.Op {
+	case "add":
+		current.Add(current, value)
+	case "percent":
+		factor := new(big.Rat).Add(big.NewRat(1, 1), new(big.Rat).Quo(value, big.NewRat(100, 1)))
+		current.Mul(current, factor)
+	}
+	// Keep the precision the price was written with, and at least cents.
+	scale := product.Price.Scale()
+	if scale < 2 {
+		scale = 2
+	}
+	price := types.MoneyFromRat(current, product.Price.Currency, scale)
+	patch.Price = &price
+	return patch, NormalizePrice(patch.Price)
+}
+
+// applyBatchTarget writes one product of a batch. Ledger entries and price history are
+// collected into the given slices when they are not nil, to be inserted with the rest of
+// an atomic batch, and are stored straight away otherwise.
+func applyBatchTarget(ctx context.Context, userID string, batchID string, target batchTarget, ledger *[]interface{}, priceChanges *[]interface{}) BatchResult {
+	product := target.product
+	result := BatchResult{ProductID: product.ProductID, OldPrice: product.Price, NewPrice: product.Price, OldStock: product.Stock, NewStock: product.Stock}
+	if target.err == nil && ProductStatus(product) == types.ProductArchived {
+		target.err = ErrProductArchived
+	}
+	if target.err == nil && target.patch.Stock != nil && product.Kind == string(types.Bundle) {
+		target.err = ErrBundleStock
+	}
+	// Discontinued products cannot be received, so a batch may only lower their stock.
+	if target.err == nil && target.patch.Stock != nil && *target.patch.Stock > product.Stock &&
+		ProductStatus(product) == types.ProductDiscontinued {
+		target.err = ErrProductDiscontinued
+	}
+	if target.err != nil {
+		result.Status = "failed"
+		result.Error = target.err.Error()
+		return result
+	}
+
+	set := bson.M{}
+	filter := bson.M{"productid": product.ProductID}
+	priceChange, priceChanged := NewPriceChange(userID, product.ProductID, product.Price, target.patch.Price)
+	if priceChanged {
+		set["price"] = target.patch.Price
+		result.NewPrice = target.patch.Price
+	}
+	if target.patch.Stock != nil && *target.patch.Stock != product.Stock {
+		set["stock"] = *target.patch.Stock
+		// Only overwrite the stock that was read, so that movements made in the meantime are not lost.
+		filter["stock"] = product.Stock
+		result.NewStock = *target.patch.Stock
+	}
+	if len(set) == 0 {
+		result.Status = "unchanged"
+		return result
+	}
+	set["updatedat"], _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	updated, err := productCollection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err == nil && updated.MatchedCount == 0 {
+		err = ErrStockChanged
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "updated"
+
+	// One ledger entry per changed product. A stock change is recorded as a movement by
+	// the difference, so that it is picked up like any other movement.
+	transaction := models.Transaction{
+		UserID:      userID,
+		ProductID:   product.ProductID,
+		ProcessType: strconv.Itoa(int(types.Update)),
+		BatchID:     batchID,
+	}
+	if result.NewStock > result.OldStock {
+		transaction.Direction = string(types.Inbound)
+		transaction.Amount = result.NewStock - result.OldStock
+	} else if result.NewStock < result.OldStock {
+		transaction.Direction = string(types.Outbound)
+		transaction.Amount = result.OldStock - result.NewStock
+	}
+
+	if ledger != nil {
+		*ledger = append(*ledger, NewTransaction(transaction))
+		if priceChanged {
+			*priceChanges = append(*priceChanges, priceChange)
+		}
+		return result
+	}
+
+	RecordTransaction(transaction)
+	if priceChanged {
+		if _, err := priceChangeCollection.InsertOne(ctx, priceChange); err != nil {
+			log.Printf("Error while inserting price change: %v", err)
+		}
+	}
+	return result
+}
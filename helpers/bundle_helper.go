@@ -0,0 +1,243 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/bundle"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrBundleComponents = errors.New("a bundle needs at least one component")
+	ErrBundleStock      = errors.New("bundles have no stock of their own, receive their components instead")
+)
+
+// ValidateBundle checks the kind and components of a product that is added, with an
+// empty productID, or fully updated. Components must be existing products that are not
+// bundles themselves, and a bundle keeps no stock of its own.
+func ValidateBundle(ctx context.Context, productID string, product *models.Product) error {
+	if product.Kind == "" {
+		product.Kind = string(types.StandardProduct)
+	}
+	if product.Kind != string(types.Bundle) {
+		if len(product.Components) > 0 {
+			return errors.New("only bundles can have components")
+		}
+		product.Components = nil
+		return nil
+	}
+	if len(product.Components) == 0 {
+		return ErrBundleComponents
+	}
+
+	ids := make([]string, 0, len(product.Components))
+	seen := map[string]bool{}
+	for _, component := range product.Components {
+		if component.ProductID == productID && productID != "" {
+			return errors.New("a bundle cannot be a component of itself")
+		}
+		if seen[component.ProductID] {
+			return fmt.Errorf("component %v is listed twice", component.ProductID)
+		}
+		seen[component.ProductID] = true
+		ids = append(ids, component.ProductID)
+	}
+
+	cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"productid": 1, "kind": 1}))
+	if err != nil {
+		return err
+	}
+	var components []models.Product
+	if err = cursor.All(ctx, &components); err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, component := range components {
+		if component.Kind == string(types.Bundle) {
+			return fmt.Errorf("component %v is a bundle, bundles cannot be nested", component.ProductID)
+		}
+		found[component.ProductID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("component %v not found", id)
+		}
+	}
+
+	if productID != "" {
+		used, err := BundlesUsing(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if used > 0 {
+			return errors.New("a component of a bundle cannot become a bundle")
+		}
+
+		var stored models.Product
+		err = productCollection.FindOne(ctx, bson.M{"productid": productID},
+			options.FindOne().SetProjection(bson.M{"kind": 1, "stock": 1})).Decode(&stored)
+		if err == nil && stored.Kind != string(types.Bundle) && stored.Stock > 0 {
+			return errors.New("issue the stock of the product before turning it into a bundle")
+		}
+	}
+
+	product.Stock = 0
+	return nil
+}
+
+// BundlesUsing counts the bundles that have a product as a component.
+func BundlesUsing(ctx context.Context, productID string) (int64, error) {
+	return productCollection.CountDocuments(ctx, bson.M{"kind": string(types.Bundle), "components.productid": productID})
+}
+
+// BundleStock works out the available stock of bundles from the stock of their
+// components, by the product id of the bundle.
+func BundleStock(ctx context.Context, bundles []models.Product) (map[string]uint, error) {
+	ids := []string{}
+	for _, product := range bundles {
+		for _, component := range product.Components {
+			ids = append(ids, component.ProductID)
+		}
+	}
+
+	stock := map[string]uint{}
+	if len(ids) > 0 {
+		cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"productid": 1, "stock": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var components []models.Product
+		if err = cursor.All(ctx, &components); err != nil {
+			return nil, err
+		}
+		for _, component := range components {
+			stock[component.ProductID] = component.Stock
+		}
+	}
+
+	available := map[string]uint{}
+	for _, product := range bundles {
+		available[product.ProductID] = bundle.Available(product.Components, stock)
+	}
+	return available, nil
+}
+
+// FillBundleStock replaces the stock of the bundles on a page of the product list with
+// the stock computed from their components.
+func FillBundleStock(ctx context.Context, items primitive.A) error {
+	ids := []string{}
+	for _, item := range items {
+		if product, ok := item.(bson.M); ok && product["kind"] == string(types.Bundle) {
+			if id, ok := product["productid"].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"productid": 1, "components": 1}))
+	if err != nil {
+		return err
+	}
+	var bundles []models.Product
+	if err = cursor.All(ctx, &bundles); err != nil {
+		return err
+	}
+	available, err := BundleStock(ctx, bundles)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if product, ok := item.(bson.M); ok && product["kind"] == string(types.Bundle) {
+			product["stock"] = available[fmt.Sprint(product["productid"])]
+		}
+	}
+	return nil
+}
+
+// IssueBundle issues a quantity of a bundle by issuing its components, all in one database
+// transaction. The ledger gets an outbound entry for each component and an entry without
+// a direction for the bundle, all sharing one batch id.
+func IssueBundle(ctx context.Context, userID string, product models.Product, unit models.ProductUnit, movement models.StockMovement) error {
+	amount, err := ToBaseQuantity(unit, movement.Quantity)
+	if err != nil {
+		return err
+	}
+	needed, err := bundle.Requirements(product.Components, amount)
+	if errors.Is(err, bundle.ErrQuantityTooLarge) {
+		return ErrQuantityTooLarge
+	}
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(needed))
+	for id := range needed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	batchID := primitive.NewObjectID().Hex()
+
+	return database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		// The bundle is checked again inside the transaction in case it was archived since it was read.
+		if err := CheckProductsWritable(sessCtx, append([]string{product.ProductID}, ids...)...); err != nil {
+			return err
+		}
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		ledger := []interface{}{NewTransaction(models.Transaction{
+			UserID:       userID,
+			ProductID:    product.ProductID,
+			ProcessType:  strconv.Itoa(int(types.Issue)),
+			Amount:       amount,
+			Unit:         unit.Name,
+			UnitQuantity: movement.Quantity,
+			BatchID:      batchID,
+		})}
+
+		for _, id := range ids {
+			result, err := productCollection.UpdateOne(sessCtx,
+				bson.M{"productid": id, "stock": bson.M{"$gte": needed[id]}},
+				bson.M{
+					"$inc": bson.M{"stock": -int64(needed[id])},
+					"$set": bson.M{"updatedat": updatedAt},
+				},
+			)
+			if err != nil {
+				return err
+			}
+			if result.MatchedCount == 0 {
+				return fmt.Errorf("%w for component %v", ErrInsufficientStock, id)
+			}
+
+			ledger = append(ledger, NewTransaction(models.Transaction{
+				UserID:      userID,
+				ProductID:   id,
+				ProcessType: strconv.Itoa(int(types.Issue)),
+				Amount:      needed[id],
+				Direction:   string(types.Outbound),
+				BatchID:     batchID,
+			}))
+		}
+
+		_, err := transactionCollection.InsertMany(sessCtx, ledger)
+		return err
+	})
+}
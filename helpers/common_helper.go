@@ -1,7 +1,9 @@
 package helpers
 
 import (
+	"fmt"
 	"reflect"
+	"time"
 )
 
 func HasValue(values ...interface{}) bool {
@@ -36,3 +38,19 @@ func HasValue(values ...interface{}) bool {
 	}
 	return true
 }
+
+// ParseDate reads an RFC 3339 time or a plain 2006-01-02 date. A plain date used as the
+// end of a range covers the whole day.
+func ParseDate(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,190 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/stocktake"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var countSessionCollection *mongo.Collection = database.OpenCollection(database.Client, "countsession")
+
+var ErrCountSessionNotOpen = errors.New("count session is not open")
+
+// CountLines freezes the current stock of every product in the scope of a session.
+func CountLines(ctx context.Context, categories []int, locations []string) ([]models.CountLine, error) {
+	// Archived products are read-only and bundles have no stock of their own, so neither is counted.
+	filter := bson.M{"status": bson.M{"$ne": string(types.ProductArchived)}, "kind": bson.M{"$ne": string(types.Bundle)}}
+	if len(categories) > 0 {
+		filter["category"] = bson.M{"$in": categories}
+	}
+	if len(locations) > 0 {
+		filter["location"] = bson.M{"$in": locations}
+	}
+
+	cursor, err := productCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	lines := make([]models.CountLine, 0, len(products))
+	for _, product := range products {
+		line := models.CountLine{
+			ProductID: product.ProductID,
+			Barcode:   product.Barcode,
+			Expected:  product.Stock,
+		}
+		if product.Name != nil {
+			line.Name = *product.Name
+		}
+		if product.Location != nil {
+			line.Location = *product.Location
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// FillVariances sets the variance of every counted line of a session.
+func FillVariances(session *models.CountSession) {
+	stocktake.FillVariances(session.Lines)
+}
+
+// ResolveCountEntry finds the product of a count entry and converts its quantity to the
+// base unit. Barcodes may belong to the product itself or to one of its pack units.
+func ResolveCountEntry(ctx context.Context, entry models.CountEntry) (string, uint, error) {
+	filter, err := stocktake.EntryFilter(entry)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var product models.Product
+	if err := productCollection.FindOne(ctx, filter).Decode(&product); err != nil {
+		return "", 0, fmt.Errorf("no product found for entry %v%v", entry.ProductID, entry.Barcode)
+	}
+	if product.BaseUnit == "" {
+		product.BaseUnit = DefaultBaseUnit
+	}
+
+	base, err := stocktake.EntryQuantity(product, entry)
+	if err != nil {
+		return "", 0, err
+	}
+	return product.ProductID, base, nil
+}
+
+// SubmitCount adds to or replaces the counted quantity of one line of an open session.
+func SubmitCount(ctx context.Context, sessionID string, productID string, quantity uint, mode string, userID string) error {
+	countedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	update := bson.M{"$set": bson.M{
+		"lines.$.countedby": userID,
+		"lines.$.countedat": countedAt,
+	}}
+	if mode == "set" {
+		update["$set"].(bson.M)["lines.$.counted"] = quantity
+	} else {
+		update["$inc"] = bson.M{"lines.$.counted": quantity}
+	}
+
+	filter := bson.M{
+		"sessionid":       sessionID,
+		"status":          string(types.CountOpen),
+		"lines.productid": productID,
+	}
+	result, err := countSessionCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product %v is not part of an open count session %v", productID, sessionID)
+	}
+	return nil
+}
+
+// PostCountSession posts every non-zero variance of an open session as a count correction
+// and closes the session, all in one database transaction. The ledger entries share the
+// session id as their batch id.
+func PostCountSession(ctx context.Context, sessionID string, userID string) (models.CountSession, error) {
+	var session models.CountSession
+
+	err := database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		err := countSessionCollection.FindOne(sessCtx, bson.M{"sessionid": sessionID}).Decode(&session)
+		if err != nil {
+			return err
+		}
+		if session.Status != string(types.CountOpen) {
+			return ErrCountSessionNotOpen
+		}
+		FillVariances(&session)
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		var ledger []interface{}
+
+		for _, line := range session.Lines {
+			if line.Variance == nil {
+				continue
+			}
+			direction, amount, ok := stocktake.Correction(*line.Variance)
+			if !ok {
+				continue
+			}
+
+			filter := bson.M{"productid": line.ProductID}
+			if direction == types.Outbound {
+				filter["stock"] = bson.M{"$gte": amount}
+			}
+
+			result, err := productCollection.UpdateOne(sessCtx, filter, bson.M{
+				"$inc": bson.M{"stock": *line.Variance},
+				"$set": bson.M{"updatedat": updatedAt},
+			})
+			if err != nil {
+				return err
+			}
+			if result.MatchedCount == 0 {
+				return fmt.Errorf("%w for product %v", ErrInsufficientStock, line.ProductID)
+			}
+
+			ledger = append(ledger, NewTransaction(models.Transaction{
+				UserID:      userID,
+				ProductID:   line.ProductID,
+				ProcessType: strconv.Itoa(int(types.CountCorrection)),
+				Amount:      amount,
+				Direction:   string(direction),
+				BatchID:     session.SessionID,
+			}))
+		}
+
+		if len(ledger) > 0 {
+			if _, err := transactionCollection.InsertMany(sessCtx, ledger); err != nil {
+				return err
+			}
+		}
+
+		session.Status = string(types.CountPosted)
+		session.PostedBy = userID
+		session.PostedAt = updatedAt
+
+		_, err = countSessionCollection.UpdateOne(sessCtx, bson.M{"sessionid": sessionID}, bson.M{"$set": bson.M{
+			"status":   session.Status,
+			"postedby": session.PostedBy,
+			"postedat": session.PostedAt,
+		}})
+		return err
+	})
+
+	return session, err
+}
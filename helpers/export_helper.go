@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+)
+
+// ProductColumns are the CSV columns of an exported product.
+var ProductColumns = []string{
+	"productid", "barcode", "name", "description", "category", "price", "currency",
+	"stock", "baseunit", "location", "status", "createdat", "updatedat",
+}
+
+// ProductRow flattens a product into ProductColumns.
+func ProductRow(product models.Product) []string {
+	row := make([]string, 0, len(ProductColumns))
+	category := ""
+	if product.Category != nil {
+		category = types.CategoryTypes(*product.Category).String()
+	}
+	price, currency := "", ""
+	if product.Price != nil {
+		price, currency = product.Price.Amount.String(), product.Price.Currency
+	}
+	status, _ := types.ParseProductStatus(product.Status)
+	return append(row,
+		product.ProductID, product.Barcode, stringValue(product.Name), stringValue(product.Description),
+		category, price, currency, strconv.FormatUint(uint64(product.Stock), 10), product.BaseUnit,
+		stringValue(product.Location), string(status), formatTime(product.CreatedAt), formatTime(product.UpdatedAt),
+	)
+}
+
+// TransactionColumns are the CSV columns of an exported transaction.
+var TransactionColumns = []string{
+	"transactionid", "processtime", "userid", "productid", "processtype", "direction",
+	"amount", "unit", "unitquantity", "unitcost", "currency", "batchid", "fromstatus", "tostatus",
+}
+
+// TransactionRow flattens a transaction into TransactionColumns.
+func TransactionRow(transaction models.Transaction) []string {
+	unitCost, currency := "", ""
+	if transaction.UnitCost != nil {
+		unitCost, currency = transaction.UnitCost.Amount.String(), transaction.UnitCost.Currency
+	}
+	return []string{
+		transaction.TransactionID, formatTime(transaction.ProcessTime), transaction.UserID,
+		transaction.ProductID, transaction.ProcessType, transaction.Direction,
+		strconv.FormatUint(uint64(transaction.Amount), 10), transaction.Unit,
+		strconv.FormatUint(uint64(transaction.UnitQuantity), 10), unitCost, currency, transaction.BatchID,
+		transaction.FromStatus, transaction.ToStatus,
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,200 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultPriceBuckets are the price boundaries used when a request does not set its own.
+var DefaultPriceBuckets = []string{"0", "10", "25", "50", "100", "250", "500", "1000"}
+
+// maxDecimal is the largest Decimal128, used to close the last price bucket.
+var maxDecimal, _ = primitive.ParseDecimal128("9.999999999999999999999999999999999E+6144")
+
+// DefaultLowStock is the stock at or below which a product counts as low on stock.
+const DefaultLowStock = 5
+
+// CategoryFacet is the number of products in a category.
+type CategoryFacet struct {
+	Category int    `json:"category"`
+	Name     string `json:"name"`
+	Count    int64  `json:"count"`
+}
+
+// PriceFacet is the number of products priced from Min up to but not including Max. The
+// last bucket has no Max, and products without a price or priced below the first
+// boundary are counted in a bucket with neither.
+type PriceFacet struct {
+	Min   *string `json:"min"`
+	Max   *string `json:"max"`
+	Count int64   `json:"count"`
+}
+
+// StockFacet is the number of products with a stock status of outofstock, low or instock.
+type StockFacet struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// ProductFacets are the counts shown next to a filtered product list.
+type ProductFacets struct {
+	Categories []CategoryFacet `json:"categories"`
+	Prices     []PriceFacet    `json:"prices"`
+	Stock      []StockFacet    `json:"stock"`
+}
+
+// FacetOptions reads the price boundaries, as "pricebuckets=0,10,50", and the low stock
+// threshold, as "lowstock=5", from the query string.
+func FacetOptions(query url.Values) ([]primitive.Decimal128, uint, error) {
+	boundaries := DefaultPriceBuckets
+	if value := query.Get("pricebuckets"); value != "" {
+		boundaries = strings.Split(value, ",")
+	}
+	buckets := make([]primitive.Decimal128, 0, len(boundaries))
+	for i, boundary := range boundaries {
+		price, err := types.NewMoney(boundary, "")
+		if err != nil {
+			return nil, 0, fmt.Errorf("pricebuckets: %w", err)
+		}
+		if i > 0 && price.Cmp(types.Money{Amount: buckets[i-1]}) <= 0 {
+			return nil, 0, errors.New("pricebuckets must be in increasing order")
+		}
+		buckets = append(buckets, price.Amount)
+	}
+
+	lowStock := uint(DefaultLowStock)
+	if value := query.Get("lowstock"); value != "" {
+		number, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, 0, errors.New("lowstock must be a whole number")
+		}
+		lowStock = uint(number)
+	}
+	return buckets, lowStock, nil
+}
+
+// FacetProducts counts the products matching the filter per category, price bucket and
+// stock status in one $facet aggregation.
+func FacetProducts(ctx context.Context, filter bson.D, priceBuckets []primitive.Decimal128, lowStock uint) (ProductFacets, error) {
+	boundaries := make([]interface{}, 0, len(priceBuckets)+1)
+	for _, boundary := range priceBuckets {
+		boundaries = append(boundaries, boundary)
+	}
+	// Close the last bucket with the largest decimal so that it takes every price above
+	// the last boundary and only products without a price fall into the default bucket.
+	boundaries = append(boundaries, maxDecimal)
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "categories", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$category"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+			}},
+			{Key: "prices", Value: bson.A{
+				bson.D{{Key: "$bucket", Value: bson.D{
+					{Key: "groupBy", Value: priceAmount},
+					{Key: "boundaries", Value: boundaries},
+					{Key: "default", Value: "other"},
+					{Key: "output", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}},
+				}}},
+			}},
+			{Key: "stock", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: bson.D{{Key: "$switch", Value: bson.D{
+						{Key: "branches", Value: bson.A{
+							bson.D{{Key: "case", Value: bson.D{{Key: "$lte", Value: bson.A{"$stock", 0}}}}, {Key: "then", Value: "outofstock"}},
+							bson.D{{Key: "case", Value: bson.D{{Key: "$lte", Value: bson.A{"$stock", lowStock}}}}, {Key: "then", Value: "low"}},
+						}},
+						{Key: "default", Value: "instock"},
+					}}}},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+			}},
+		}}},
+	}
+
+	facets := ProductFacets{Categories: []CategoryFacet{}, Prices: []PriceFacet{}, Stock: []StockFacet{}}
+
+	cursor, err := productCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return facets, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Categories []struct {
+			ID    *int  `bson:"_id"`
+			Count int64 `bson:"count"`
+		} `bson:"categories"`
+		Prices []struct {
+			ID    interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"prices"`
+		Stock []struct {
+			ID    string `bson:"_id"`
+			Count int64  `bson:"count"`
+		} `bson:"stock"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return facets, err
+	}
+	if len(results) == 0 {
+		return facets, nil
+	}
+	result := results[0]
+
+	for _, category := range result.Categories {
+		if category.ID == nil {
+			continue
+		}
+		facets.Categories = append(facets.Categories, CategoryFacet{
+			Category: *category.ID,
+			Name:     types.CategoryTypes(*category.ID).String(),
+			Count:    category.Count,
+		})
+	}
+	sort.Slice(facets.Categories, func(i, j int) bool { return facets.Categories[i].Category < facets.Categories[j].Category })
+
+	// Report every bucket, empty ones included, so the buckets line up with the boundaries.
+	counts := map[string]int64{}
+	for _, bucket := range result.Prices {
+		if lower, ok := bucket.ID.(primitive.Decimal128); ok {
+			counts[lower.String()] = bucket.Count
+		} else {
+			counts["other"] = bucket.Count
+		}
+	}
+	for i, boundary := range priceBuckets {
+		min := boundary.String()
+		facet := PriceFacet{Min: &min, Count: counts[min]}
+		if i+1 < len(priceBuckets) {
+			max := priceBuckets[i+1].String()
+			facet.Max = &max
+		}
+		facets.Prices = append(facets.Prices, facet)
+	}
+	if counts["other"] > 0 {
+		facets.Prices = append(facets.Prices, PriceFacet{Count: counts["other"]})
+	}
+
+	for _, status := range []string{"outofstock", "low", "instock"} {
+		facet := StockFacet{Status: status}
+		for _, stock := range result.Stock {
+			if stock.ID == status {
+				facet.Count = stock.Count
+			}
+		}
+		facets.Stock = append(facets.Stock, facet)
+	}
+	return facets, nil
+}
@@ -0,0 +1,268 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	productquery "github.com/Deatsilence/go-stocket/pkg/query"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProductSortFields maps the names the product list can be sorted by to their fields.
+var ProductSortFields = map[string]string{
+	"name":      "name",
+	"barcode":   "barcode",
+	"category":  "category",
+	"price":     "priceamount",
+	"stock":     "stock",
+	"createdat": "createdat",
+	"updatedat": "updatedat",
+}
+
+// priceAmount is the amount of the price of a product. Prices saved before Money existed
+// are plain doubles in the base currency.
+var priceAmount = bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$isNumber", Value: "$price"}}, "$price", "$price.amount"}}}
+
+// productSortExpressions are the sort keys that are computed from other fields.
+var productSortExpressions = map[string]interface{}{
+	"priceamount": priceAmount,
+}
+
+// ProductFilter builds the product filter shared by the product list and its export
+// from the query string.
+func ProductFilter(query url.Values) (bson.D, error) {
+	filter := bson.D{}
+	// The query and the attribute filters are nested so their fields cannot clash with
+	// the other filters.
+	nested := bson.A{}
+	if prefix := query.Get("prefix"); prefix != "" {
+		filter = append(filter, bson.E{Key: "barcode", Value: bson.D{{Key: "$regex", Value: "^" + regexp.QuoteMeta(prefix)}, {Key: "$options", Value: "i"}}})
+	}
+
+	if value := query.Get("q"); value != "" {
+		node, err := productquery.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		compiled, err := productquery.Compile(node, productquery.ProductFields, productquery.ProductDefaultField)
+		if err != nil {
+			return nil, err
+		}
+		if len(compiled) > 0 {
+			nested = append(nested, compiled)
+		}
+	}
+
+	if value := query.Get("category"); value != "" {
+		categories := []int{}
+		for _, name := range strings.Split(value, ",") {
+			category, err := types.ParseCategory(name)
+			if err != nil {
+				return nil, err
+			}
+			categories = append(categories, int(category))
+		}
+		filter = append(filter, bson.E{Key: "category", Value: bson.M{"$in": categories}})
+	}
+
+	// Drafts are only listed when asked for by status.
+	if value := query.Get("status"); value != "" {
+		statuses := []types.ProductStatusTypes{}
+		for _, name := range strings.Split(value, ",") {
+			status, err := types.ParseProductStatus(name)
+			if err != nil || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("unknown product status %q", name)
+			}
+			statuses = append(statuses, status)
+		}
+		filter = append(filter, bson.E{Key: "status", Value: statusFilter(statuses...)})
+	} else {
+		filter = append(filter, bson.E{Key: "status", Value: bson.M{"$ne": string(types.ProductDraft)}})
+	}
+
+	price := bson.D{}
+	for _, bound := range []struct{ key, operator string }{{"minprice", "$gte"}, {"maxprice", "$lte"}} {
+		if value := query.Get(bound.key); value != "" {
+			amount, err := types.NewMoney(value, "")
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", bound.key, err)
+			}
+			price = append(price, bson.E{Key: bound.operator, Value: amount.Amount})
+		}
+	}
+	// Amounts are only comparable within a currency, so price bounds need one. Prices
+	// saved before Money existed are plain doubles in the base currency.
+	currency := strings.ToUpper(query.Get("currency"))
+	if len(price) > 0 && currency == "" {
+		return nil, errors.New("minprice and maxprice need a currency")
+	}
+	if currency != "" {
+		money := bson.M{"price.currency": currency}
+		if len(price) > 0 {
+			money["price.amount"] = price
+		}
+		if currency == BASE_CURRENCY {
+			legacy := append(bson.D{{Key: "$type", Value: "number"}}, price...)
+			nested = append(nested, bson.M{"$or": bson.A{money, bson.M{"price": legacy}}})
+		} else {
+			nested = append(nested, money)
+		}
+	}
+
+	stock := bson.D{}
+	for _, bound := range []struct{ key, operator string }{{"minstock", "$gte"}, {"maxstock", "$lte"}} {
+		if value := query.Get(bound.key); value != "" {
+			number, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a whole number", bound.key)
+			}
+			stock = append(stock, bson.E{Key: bound.operator, Value: number})
+		}
+	}
+	if value := query.Get("instock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("instock must be true or false")
+		}
+		if inStock {
+			stock = append(stock, bson.E{Key: "$gt", Value: 0})
+		} else {
+			stock = append(stock, bson.E{Key: "$lt", Value: 1})
+		}
+	}
+	if len(stock) > 0 {
+		filter = append(filter, bson.E{Key: "stock", Value: stock})
+	}
+
+	for _, field := range []string{"createdat", "updatedat"} {
+		dates, err := dateRange(query, field+"from", field+"to")
+		if err != nil {
+			return nil, err
+		}
+		if len(dates) > 0 {
+			filter = append(filter, bson.E{Key: field, Value: dates})
+		}
+	}
+
+	byAttribute, err := attributeFilters(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, attributeFilter := range byAttribute {
+		nested = append(nested, attributeFilter)
+	}
+	if len(nested) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: nested})
+	}
+	return filter, nil
+}
+
+// ProductSort reads a sort such as "category,-price" where a leading minus sorts that
+// field in descending order. Ties are broken by insertion order.
+func ProductSort(query url.Values) (bson.D, error) {
+	sort := bson.D{}
+	seen := map[string]bool{}
+	if value := query.Get("sort"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			direction := 1
+			if strings.HasPrefix(name, "-") {
+				name, direction = name[1:], -1
+			} else if strings.HasPrefix(name, "+") {
+				name = name[1:]
+			}
+			field, ok := ProductSortFields[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("cannot sort by %q", name)
+			}
+			if seen[field] {
+				return nil, fmt.Errorf("cannot sort by %q more than once", name)
+			}
+			seen[field] = true
+			sort = append(sort, bson.E{Key: field, Value: direction})
+		}
+	}
+	return append(sort, bson.E{Key: "_id", Value: 1}), nil
+}
+
+// ProductSortStages sorts products, computing the sort keys that are not stored on the
+// products first and dropping them again afterwards.
+func ProductSortStages(sort bson.D) mongo.Pipeline {
+	computed := bson.D{}
+	names := bson.A{}
+	for _, key := range sort {
+		if expression, ok := productSortExpressions[key.Key]; ok {
+			computed = append(computed, bson.E{Key: key.Key, Value: expression})
+			names = append(names, key.Key)
+		}
+	}
+	if len(computed) == 0 {
+		return mongo.Pipeline{bson.D{{Key: "$sort", Value: sort}}}
+	}
+	return mongo.Pipeline{
+		bson.D{{Key: "$addFields", Value: computed}},
+		bson.D{{Key: "$sort", Value: sort}},
+		bson.D{{Key: "$unset", Value: names}},
+	}
+}
+
+// dateRange reads an inclusive range of dates from two query parameters.
+func dateRange(query url.Values, fromKey string, toKey string) (bson.D, error) {
+	dates := bson.D{}
+	if value := query.Get(fromKey); value != "" {
+		from, err := ParseDate(value, false)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, bson.E{Key: "$gte", Value: from})
+	}
+	if value := query.Get(toKey); value != "" {
+		to, err := ParseDate(value, true)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, bson.E{Key: "$lte", Value: to})
+	}
+	return dates, nil
+}
+
+// TransactionFilter builds the ledger filter shared by the transaction list and its
+// export from the query string.
+func TransactionFilter(query url.Values) (bson.D, error) {
+	filter := bson.D{}
+	for _, key := range []string{"productid", "userid", "batchid"} {
+		if value := query.Get(key); value != "" {
+			filter = append(filter, bson.E{Key: key, Value: value})
+		}
+	}
+
+	if value := query.Get("processtype"); value != "" {
+		processType, err := strconv.Atoi(value)
+		if err != nil || processType < int(types.Add) || processType > int(types.StatusChange) {
+			return nil, errors.New("processtype must be a number between 0 and 8")
+		}
+		filter = append(filter, bson.E{Key: "processtype", Value: strconv.Itoa(processType)})
+	}
+
+	if value := query.Get("direction"); value != "" {
+		if value != string(types.Inbound) && value != string(types.Outbound) {
+			return nil, errors.New("direction must be in or out")
+		}
+		filter = append(filter, bson.E{Key: "direction", Value: value})
+	}
+
+	processTime, err := dateRange(query, "from", "to")
+	if err != nil {
+		return nil, err
+	}
+	if len(processTime) > 0 {
+		filter = append(filter, bson.E{Key: "processtime", Value: processTime})
+	}
+	return filter, nil
+}
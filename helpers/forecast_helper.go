@@ -0,0 +1,209 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/analytics"
+	"github.com/Deatsilence/go-stocket/pkg/forecast"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrForecastBundle = errors.New("bundles have no stock of their own, forecast their components instead")
+
+// ProductForecast is the demand forecast of a product from today, when its stock is
+// expected to run out and the reorder suggested for it.
+type ProductForecast struct {
+	ProductID    string              `json:"productid"`
+	Barcode      string              `json:"barcode"`
+	Name         string              `json:"name"`
+	Stock        uint                `json:"stock"`
+	HistoryDays  int                 `json:"historydays"`
+	Forecast     forecast.Result     `json:"forecast"`
+	StockOutDate *time.Time          `json:"stockoutdate"`
+	DaysLeft     *int                `json:"daysleft"`
+	Reorder      forecast.Suggestion `json:"reorder"`
+}
+
+// ForecastProduct forecasts the demand of one product.
+func ForecastProduct(ctx context.Context, productID string, opts forecast.Options) (ProductForecast, error) {
+	var product models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID}).Decode(&product)
+	if err != nil {
+		return ProductForecast{}, err
+	}
+	if product.Kind == string(types.Bundle) {
+		return ProductForecast{}, ErrForecastBundle
+	}
+
+	today := analytics.Truncate(time.Now(), analytics.Day)
+	demand, err := dailyDemand(ctx, []string{productID}, today, opts.HistoryDays)
+	if err != nil {
+		return ProductForecast{}, err
+	}
+	return forecastProduct(product, demand[productID], today, opts)
+}
+
+// StockOutReport forecasts the active products in the filter and lists those expected to
+// run out of stock within the horizon, soonest first.
+func StockOutReport(ctx context.Context, productFilter bson.M, opts forecast.Options) ([]ProductForecast, error) {
+	filter := bson.M{"status": statusFilter(types.ProductActive), "kind": bson.M{"$ne": string(types.Bundle)}}
+	for key, value := range productFilter {
+		filter[key] = value
+	}
+	cursor, err := productCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{
+		"productid": 1, "barcode": 1, "name": 1, "stock": 1, "createdat": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ProductID)
+	}
+	today := analytics.Truncate(time.Now(), analytics.Day)
+	demand, err := dailyDemand(ctx, ids, today, opts.HistoryDays)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []ProductForecast{}
+	for _, product := range products {
+		productOpts := opts
+		history := demandSince(demand[product.ProductID], product, today)
+		// Products too new for the seasonal method are still reported.
+		if opts.Method == forecast.Seasonal && len(history) < forecast.MinSeasonalHistory {
+			productOpts.Method = forecast.Smoothing
+		}
+		predicted, err := forecastProduct(product, demand[product.ProductID], today, productOpts)
+		if err != nil {
+			return nil, err
+		}
+		if predicted.DaysLeft != nil {
+			report = append(report, predicted)
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if *report[i].DaysLeft != *report[j].DaysLeft {
+			return *report[i].DaysLeft < *report[j].DaysLeft
+		}
+		return report[i].ProductID < report[j].ProductID
+	})
+	return report, nil
+}
+
+func forecastProduct(product models.Product, demand []float64, today time.Time, opts forecast.Options) (ProductForecast, error) {
+	history := demandSince(demand, product, today)
+
+	result, err := forecast.Forecast(history, opts.Method, opts.Horizon)
+	if err != nil {
+		return ProductForecast{}, err
+	}
+	suggestion, err := forecast.Reorder(result, product.Stock, opts.Policy)
+	if err != nil {
+		return ProductForecast{}, err
+	}
+
+	predicted := ProductForecast{
+		ProductID:   product.ProductID,
+		Barcode:     product.Barcode,
+		Name:        stringValue(product.Name),
+		Stock:       product.Stock,
+		HistoryDays: len(history),
+		Forecast:    result,
+		Reorder:     suggestion,
+	}
+	if day, ok := forecast.StockOut(result, product.Stock); ok {
+		date := today.AddDate(0, 0, day)
+		predicted.StockOutDate = &date
+		predicted.DaysLeft = &day
+	}
+	return predicted, nil
+}
+
+// demandSince drops the days of the history before the product was created, so that
+// they do not count as days without demand.
+func demandSince(demand []float64, product models.Product, today time.Time) []float64 {
+	if product.CreatedAt.IsZero() {
+		return demand
+	}
+	start := today.AddDate(0, 0, -len(demand))
+	created := analytics.Truncate(product.CreatedAt, analytics.Day)
+	if skip := int(created.Sub(start).Hours() / 24); skip > 0 {
+		if skip > len(demand) {
+			skip = len(demand)
+		}
+		return demand[skip:]
+	}
+	return demand
+}
+
+// dailyDemand sums the issues and work order consumption of the products per day over
+// the days before today, oldest first. Corrections, updates and deletions are not demand.
+// Today is left out as it is not over yet.
+func dailyDemand(ctx context.Context, productIDs []string, today time.Time, days int) (map[string][]float64, error) {
+	demand := map[string][]float64{}
+	for _, id := range productIDs {
+		demand[id] = make([]float64, days)
+	}
+	if len(productIDs) == 0 {
+		return demand, nil
+	}
+	start := today.AddDate(0, 0, -days)
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"productid":   bson.M{"$in": productIDs},
+			"direction":   string(types.Outbound),
+			"processtype": bson.M{"$in": []string{strconv.Itoa(int(types.Issue)), strconv.Itoa(int(types.Consume))}},
+			"processtime": bson.M{"$gte": start, "$lt": today},
+		}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "productid", Value: "$productid"},
+				{Key: "day", Value: bson.D{{Key: "$dateTrunc", Value: bson.D{
+					{Key: "date", Value: "$processtime"},
+					{Key: "unit", Value: "day"},
+					{Key: "timezone", Value: "UTC"},
+				}}}},
+			}},
+			{Key: "quantity", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, err := transactionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID struct {
+			ProductID string    `bson:"productid"`
+			Day       time.Time `bson:"day"`
+		} `bson:"_id"`
+		Quantity int64 `bson:"quantity"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		day := int(result.ID.Day.Sub(start).Hours() / 24)
+		if series, ok := demand[result.ID.ProductID]; ok && day >= 0 && day < len(series) {
+			series[day] = float64(result.Quantity)
+		}
+	}
+	return demand, nil
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/importer"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validateImport = NewValidator()
+
+// ImportResult is the outcome of one imported row.
+type ImportResult struct {
+	Row       int      `json:"row"`
+	Action    string   `json:"action"` /// add, update or error
+	Barcode   string   `json:"barcode"`
+	ProductID string   `json:"productid,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ImportSummary counts the outcomes of an import and lists them per row.
+type ImportSummary struct {
+	DryRun  bool           `json:"dryrun"`
+	Total   int            `json:"total"`
+	Added   int            `json:"added"`
+	Updated int            `json:"updated"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportResult `json:"rows"`
+}
+
+// ImportProducts upserts the rows by barcode, validating every merged product with the
+// same rules as a product sent to the API. A dry run validates and reports the action
+// each row would take without writing anything.
+func ImportProducts(ctx context.Context, userID string, rows []importer.Row, dryRun bool) (ImportSummary, error) {
+	summary := ImportSummary{DryRun: dryRun, Total: len(rows), Rows: make([]ImportResult, 0, len(rows))}
+	// Products seen earlier in the same file, so a repeated barcode updates the earlier row.
+	pending := map[string]models.Product{}
+
+	for _, row := range rows {
+		result := ImportResult{Row: row.Number, Barcode: row.Product.Barcode, Errors: row.Errors}
+
+		product, stored, existing, err := mergeImportRow(ctx, row, pending)
+		if err != nil {
+			return summary, err
+		}
+		if len(result.Errors) == 0 {
+			result.Errors = validateImportedProduct(ctx, row, stored, &product)
+		}
+
+		if len(result.Errors) > 0 {
+			result.Action = "error"
+			summary.Failed++
+			summary.Rows = append(summary.Rows, result)
+			continue
+		}
+
+		result.Action = "add"
+		if existing {
+			result.Action = "update"
+		}
+
+		if !dryRun {
+			if product.Barcode == "" {
+				if product.Barcode, err = GenerateInternalBarcode(ctx); err != nil {
+					return summary, err
+				}
+			}
+			if err := saveImportedProduct(ctx, userID, &product, existing); err != nil {
+				return summary, err
+			}
+		}
+		if product.Barcode != "" {
+			pending[product.Barcode] = product
+		}
+
+		result.Barcode = product.Barcode
+		result.ProductID = product.ProductID
+		if existing {
+			summary.Updated++
+		} else {
+			summary.Added++
+		}
+		summary.Rows = append(summary.Rows, result)
+	}
+	return summary, nil
+}
+
+// mergeImportRow lays the fields of a row over the product with the same barcode, if any,
+// and returns the merged product along with the product as it was before the row.
+func mergeImportRow(ctx context.Context, row importer.Row, pending map[string]models.Product) (models.Product, models.Product, bool, error) {
+	var product models.Product
+	existing := false
+
+	if barcode := row.Product.Barcode; barcode != "" {
+		if found, ok := pending[barcode]; ok {
+			product, existing = found, true
+		} else {
+			err := productCollection.FindOne(ctx, bson.M{"barcode": barcode}).Decode(&product)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return product, product, false, err
+			}
+			existing = err == nil
+		}
+	}
+
+	stored := product
+	incoming := row.Product
+	product.Barcode = incoming.Barcode
+	if incoming.Name != nil {
+		product.Name = incoming.Name
+	}
+	if incoming.Description != nil {
+		product.Description = incoming.Description
+	}
+	if incoming.Category != nil {
+		product.Category = incoming.Category
+	}
+	if incoming.Price != nil {
+		product.Price = incoming.Price
+	}
+	if row.HasStock {
+		product.Stock = incoming.Stock
+	}
+	if incoming.BaseUnit != "" {
+		product.BaseUnit = incoming.BaseUnit
+	}
+	if incoming.Location != nil {
+		product.Location = incoming.Location
+	}
+	if product.BaseUnit == "" {
+		product.BaseUnit = DefaultBaseUnit
+	}
+	return product, stored, existing, nil
+}
+
+func validateImportedProduct(ctx context.Context, row importer.Row, stored models.Product, product *models.Product) []string {
+	var problems []string
+
+	if err := validateImport.Struct(product); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if ProductStatus(*product) == types.ProductArchived {
+		problems = append(problems, ErrProductArchived.Error())
+	}
+	if row.HasStock && product.Kind == string(types.Bundle) {
+		problems = append(problems, ErrBundleStock.Error())
+	}
+	if stockIncreaseRefused(stored, product.Stock) {
+		problems = append(problems, ErrProductDiscontinued.Error())
+	}
+	if err := NormalizePrices(product); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := ValidateUnits(*product); err != nil {
+		problems = append(problems, err.Error())
+	}
+	// Imports have no attribute columns, so the attributes kept from the stored product
+	// must still fit the schema of the category the row leaves it in.
+	if err := ValidateProductAttributes(ctx, product); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if product.Barcode != "" && product.ProductID == "" {
+		inUse, err := BarcodeInUse(ctx, product.Barcode, "")
+		if err == nil && inUse {
+			problems = append(problems, "barcode "+product.Barcode+" is used by a pack of another product")
+		}
+	}
+	return problems
+}
+
+func saveImportedProduct(ctx context.Context, userID string, product *models.Product, existing bool) error {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	product.UpdatedAt = now
+
+	if !existing {
+		product.ID = primitive.NewObjectID()
+		product.ProductID = product.ID.Hex()
+		product.CreatedAt = now
+		if _, err := productCollection.InsertOne(ctx, product); err != nil {
+			return err
+		}
+		return CreateTransactionForProduct(userID, product.ProductID, types.Add, product.Stock)
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":        product.Name,
+		"description": product.Description,
+		"category":    product.Category,
+		"price":       product.Price,
+		"stock":       product.Stock,
+		"baseunit":    product.BaseUnit,
+		"location":    product.Location,
+		"attributes":  product.Attributes,
+		"updatedat":   product.UpdatedAt,
+	}}
+
+	var oldProduct models.Product
+	err := productCollection.FindOneAndUpdate(ctx, bson.M{"productid": product.ProductID}, update).Decode(&oldProduct)
+	if err != nil {
+		return err
+	}
+	RecordPriceChange(userID, product.ProductID, oldProduct.Price, product.Price)
+	return CreateStockUpdateTransaction(userID, product.ProductID, oldProduct.Stock, product.Stock)
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Deatsilence/go-stocket/pkg/labels"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxLabels caps the size of a single label document.
+const MaxLabels = 5000
+
+// LabelItems looks up the products of a label request and repeats each of them as many
+// times as it should be printed.
+func LabelItems(ctx context.Context, request models.LabelRequest) ([]labels.Label, error) {
+	filter := bson.M{}
+	switch {
+	case len(request.ProductIDs) > 0:
+		filter["productid"] = bson.M{"$in": request.ProductIDs}
+	case request.Category != nil:
+		filter["category"] = *request.Category
+	case request.Search != "":
+		pattern := regexp.QuoteMeta(request.Search)
+		filter["$or"] = []bson.M{
+			{"name": bson.M{"$regex": pattern, "$options": "i"}},
+			{"barcode": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+	default:
+		return nil, errors.New("select products by productids, category or search")
+	}
+
+	cursor, err := productCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	var items []labels.Label
+	for _, product := range products {
+		copies := request.Copies
+		if copies == 0 {
+			copies = 1
+		}
+		if request.PerStock {
+			copies = product.Stock
+		}
+		if quantity, ok := request.Quantities[product.ProductID]; ok {
+			copies = quantity
+		}
+		// Compared unsigned so that a huge count cannot wrap around past the cap.
+		if copies > uint(MaxLabels-len(items)) {
+			return nil, fmt.Errorf("more than %d labels requested", MaxLabels)
+		}
+
+		label := labels.Label{Barcode: product.Barcode}
+		if product.Name != nil {
+			label.Name = *product.Name
+		}
+		if product.Price != nil {
+			label.Price = product.Price.String()
+		}
+		for i := uint(0); i < copies; i++ {
+			items = append(items, label)
+		}
+	}
+	return items, nil
+}
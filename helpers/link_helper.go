@@ -0,0 +1,186 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/links"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxProductLinks is the largest number of links a product can have.
+const MaxProductLinks = 50
+
+var (
+	ErrInvalidLink       = errors.New("invalid link")
+	ErrLinkExists        = errors.New("the products are already linked that way")
+	ErrLinkNotFound      = errors.New("link not found")
+	ErrTooManyLinks      = fmt.Errorf("a product can have at most %d links", MaxProductLinks)
+	ErrAlreadySuperseded = errors.New("the product is already superseded by another product")
+)
+
+// AddProductLink links a product to another one. A product can be superseded by only one
+// product at a time.
+func AddProductLink(ctx context.Context, userID string, productID string, link models.ProductLink) (models.ProductLink, error) {
+	if link.ProductID == productID {
+		return link, fmt.Errorf("%w: a product cannot be linked to itself", ErrInvalidLink)
+	}
+
+	var product models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID},
+		options.FindOne().SetProjection(bson.M{"links": 1, "status": 1})).Decode(&product)
+	if err != nil {
+		return link, err
+	}
+	if ProductStatus(product) == types.ProductArchived {
+		return link, ErrProductArchived
+	}
+	if len(product.Links) >= MaxProductLinks {
+		return link, ErrTooManyLinks
+	}
+	for _, existing := range product.Links {
+		if existing.Type == link.Type && existing.ProductID == link.ProductID {
+			return link, ErrLinkExists
+		}
+		if existing.Type == string(types.LinkSupersededBy) && link.Type == string(types.LinkSupersededBy) {
+			return link, ErrAlreadySuperseded
+		}
+	}
+
+	count, err := productCollection.CountDocuments(ctx, bson.M{"productid": link.ProductID})
+	if err != nil {
+		return link, err
+	}
+	if count == 0 {
+		return link, fmt.Errorf("%w: linked product %v not found", ErrInvalidLink, link.ProductID)
+	}
+
+	link.CreatedBy = userID
+	link.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	// The filter repeats the checks so that links added in the meantime are not duplicated.
+	filter := bson.M{
+		"productid": productID,
+		"links":     bson.M{"$not": bson.M{"$elemMatch": bson.M{"type": link.Type, "productid": link.ProductID}}},
+	}
+	if link.Type == string(types.LinkSupersededBy) {
+		filter["links.type"] = bson.M{"$ne": string(types.LinkSupersededBy)}
+	}
+	result, err := productCollection.UpdateOne(ctx, filter, bson.M{
+		"$push": bson.M{"links": link},
+		"$set":  bson.M{"updatedat": link.CreatedAt},
+	})
+	if err != nil {
+		return link, err
+	}
+	if result.MatchedCount == 0 {
+		return link, ErrLinkExists
+	}
+	return link, nil
+}
+
+// RemoveProductLink removes a link of a type from a product to another one.
+func RemoveProductLink(ctx context.Context, productID string, linkType string, linkedID string) error {
+	if err := CheckProductsWritable(ctx, productID); err != nil {
+		return err
+	}
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	result, err := productCollection.UpdateOne(ctx,
+		bson.M{"productid": productID, "links": bson.M{"$elemMatch": bson.M{"type": linkType, "productid": linkedID}}},
+		bson.M{
+			"$pull": bson.M{"links": bson.M{"type": linkType, "productid": linkedID}},
+			"$set":  bson.M{"updatedat": updatedAt},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// RemoveLinksTo removes the links of every product to a deleted product.
+func RemoveLinksTo(ctx context.Context, productID string) error {
+	_, err := productCollection.UpdateMany(ctx,
+		bson.M{"links.productid": productID},
+		bson.M{"$pull": bson.M{"links": bson.M{"productid": productID}}},
+	)
+	return err
+}
+
+// LinkedProducts looks up the products a product links to, in the order of its links.
+// Links to products that no longer exist are left out.
+func LinkedProducts(ctx context.Context, product models.Product) ([]models.LinkedProduct, error) {
+	linked := []models.LinkedProduct{}
+	if len(product.Links) == 0 {
+		return linked, nil
+	}
+
+	ids := make([]string, 0, len(product.Links))
+	for _, link := range product.Links {
+		ids = append(ids, link.ProductID)
+	}
+	cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{
+		"productid": 1, "barcode": 1, "name": 1, "status": 1, "stock": 1, "price": 1, "kind": 1, "components": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	var bundles []models.Product
+	for _, found := range products {
+		if found.Kind == string(types.Bundle) {
+			bundles = append(bundles, found)
+		}
+	}
+	bundleStock, err := BundleStock(ctx, bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]models.Product{}
+	for _, found := range products {
+		if stock, ok := bundleStock[found.ProductID]; ok {
+			found.Stock = stock
+		}
+		byID[found.ProductID] = found
+	}
+
+	for _, link := range product.Links {
+		found, ok := byID[link.ProductID]
+		if !ok {
+			continue
+		}
+		linked = append(linked, models.LinkedProduct{
+			Type:      link.Type,
+			ProductID: found.ProductID,
+			Barcode:   found.Barcode,
+			Name:      stringValue(found.Name),
+			Status:    string(ProductStatus(found)),
+			Stock:     found.Stock,
+			Price:     found.Price,
+		})
+	}
+	return linked, nil
+}
+
+// InStockSubstitutes lists the substitutes of a product, and the product superseding it,
+// that have at least quantity in stock and can still be sold.
+func InStockSubstitutes(ctx context.Context, product models.Product, quantity uint) ([]models.LinkedProduct, error) {
+	linked, err := LinkedProducts(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	return links.Substitutes(linked, quantity), nil
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var exchangeRateCollection *mongo.Collection = database.OpenCollection(database.Client, "exchangerate")
+
+var BASE_CURRENCY string = baseCurrency()
+
+var ErrInvalidPrice = errors.New("price cannot be negative")
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+func baseCurrency() string {
+	currency := strings.ToUpper(os.Getenv("BASE_CURRENCY"))
+	if currency == "" {
+		currency = "USD"
+	}
+	return currency
+}
+
+// NormalizePrice fills in the base currency for a price given without one and rejects
+// negative amounts.
+func NormalizePrice(price *types.Money) error {
+	if price.Currency == "" {
+		price.Currency = BASE_CURRENCY
+	}
+	if price.IsNegative() {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// NormalizePrices normalizes the price of a product and checks that the alternate prices
+// are non-negative and use distinct currencies.
+func NormalizePrices(product *models.Product) error {
+	seen := map[string]bool{}
+
+	if product.Price != nil {
+		if err := NormalizePrice(product.Price); err != nil {
+			return err
+		}
+		seen[product.Price.Currency] = true
+	}
+	for _, price := range product.Prices {
+		if price.Currency == "" {
+			return errors.New("alternate prices need a currency")
+		}
+		if price.IsNegative() {
+			return fmt.Errorf("price in %s cannot be negative", price.Currency)
+		}
+		if seen[price.Currency] {
+			return fmt.Errorf("more than one price in %s", price.Currency)
+		}
+		seen[price.Currency] = true
+	}
+	return nil
+}
+
+// ConvertToBase converts money to the base currency with the admin maintained exchange rates.
+func ConvertToBase(ctx context.Context, money types.Money) (types.Money, error) {
+	if money.Currency == "" || money.Currency == BASE_CURRENCY {
+		money.Currency = BASE_CURRENCY
+		return money, nil
+	}
+
+	var rate models.ExchangeRate
+	err := exchangeRateCollection.FindOne(ctx, bson.M{"currency": money.Currency}).Decode(&rate)
+	if err != nil {
+		return types.Money{}, fmt.Errorf("%w for %s", ErrNoExchangeRate, money.Currency)
+	}
+
+	factor := types.Money{Amount: rate.Rate}.Rat()
+	converted := money.Mul(factor)
+	converted.Currency = BASE_CURRENCY
+	return converted, nil
+}
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net/smtp"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Deatsilence/go-stocket/database"
@@ -100,9 +101,16 @@ func UpdateUserPassword(email string, hashedPassword string) error {
 }
 
 func SendEmail(toEmail string, code string) error {
+	return SendMail(toEmail, "Password Reset Code", "Here is your password reset code: "+code)
+}
+
+// SendMail sends a plain text email from FROMMAIL.
+func SendMail(toEmail string, subject string, body string) error {
 	auth := smtp.PlainAuth("", FROMMAIL, FROMMAILPASSWORD, "smtp.gmail.com")
 
-	msg := "Subject: Password Reset Code\n\nHere is your password reset code: " + code
+	// Keep line breaks out of the subject so that it cannot add headers.
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	msg := "Subject: " + subject + "\n\n" + body
 
 	err := smtp.SendMail(
 		"smtp.gmail.com:587",
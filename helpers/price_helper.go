@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var priceChangeCollection *mongo.Collection = database.OpenCollection(database.Client, "pricechange")
+
+// NewPriceChange builds the history entry of an applied price change. It reports false
+// when the price did not change.
+func NewPriceChange(userID string, productID string, oldPrice *types.Money, newPrice *types.Money) (models.PriceChange, bool) {
+	if newPrice == nil || (oldPrice != nil && oldPrice.Currency == newPrice.Currency && oldPrice.Cmp(*newPrice) == 0) {
+		return models.PriceChange{}, false
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	priceChange := models.PriceChange{
+		ID:          primitive.NewObjectID(),
+		ProductID:   productID,
+		OldPrice:    oldPrice,
+		NewPrice:    newPrice,
+		Status:      string(types.PriceApplied),
+		ChangedBy:   userID,
+		CreatedAt:   now,
+		EffectiveAt: now,
+	}
+	priceChange.PriceChangeID = priceChange.ID.Hex()
+	return priceChange, true
+}
+
+// RecordPriceChange stores an applied price change in the price history of a product.
+// Nothing is stored when the price did not change.
+func RecordPriceChange(userID string, productID string, oldPrice *types.Money, newPrice *types.Money) error {
+	priceChange, changed := NewPriceChange(userID, productID, oldPrice, newPrice)
+	if !changed {
+		return nil
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := priceChangeCollection.InsertOne(ctx, priceChange)
+	if err != nil {
+		log.Printf("Error while inserting price change: %v", err)
+	}
+	return err
+}
+
+// ApplyDuePriceChanges makes every scheduled price whose effective date has passed the
+// current price of its product, oldest first.
+func ApplyDuePriceChanges(ctx context.Context) error {
+	filter := bson.M{
+		"status":      string(types.PriceScheduled),
+		"effectiveat": bson.M{"$lte": time.Now()},
+	}
+
+	cursor, err := priceChangeCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"effectiveat": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var due []models.PriceChange
+	if err = cursor.All(ctx, &due); err != nil {
+		return err
+	}
+
+	for _, priceChange := range due {
+		// Claim the change first so that two schedulers never apply it twice.
+		claim, err := priceChangeCollection.UpdateOne(ctx,
+			bson.M{"pricechangeid": priceChange.PriceChangeID, "status": string(types.PriceScheduled)},
+			bson.M{"$set": bson.M{"status": string(types.PriceApplied)}},
+		)
+		if err != nil {
+			return err
+		}
+		if claim.ModifiedCount == 0 {
+			continue
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		var product models.Product
+		err = productCollection.FindOneAndUpdate(ctx,
+			bson.M{"productid": priceChange.ProductID, "status": bson.M{"$ne": string(types.ProductArchived)}},
+			bson.M{"$set": bson.M{"price": priceChange.NewPrice, "updatedat": updatedAt}},
+		).Decode(&product)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The product was deleted or archived, so the change can never apply.
+			log.Printf("Cancelling price change %v: product %v not found or archived", priceChange.PriceChangeID, priceChange.ProductID)
+			_, err = priceChangeCollection.UpdateOne(ctx,
+				bson.M{"pricechangeid": priceChange.PriceChangeID},
+				bson.M{"$set": bson.M{"status": string(types.PriceCancelled)}},
+			)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			// Release the claim so that the next run retries the change.
+			_, releaseErr := priceChangeCollection.UpdateOne(ctx,
+				bson.M{"pricechangeid": priceChange.PriceChangeID, "status": string(types.PriceApplied)},
+				bson.M{"$set": bson.M{"status": string(types.PriceScheduled)}},
+			)
+			if releaseErr != nil {
+				log.Printf("Error while releasing price change %v: %v", priceChange.PriceChangeID, releaseErr)
+			}
+			return err
+		}
+
+		_, err = priceChangeCollection.UpdateOne(ctx,
+			bson.M{"pricechangeid": priceChange.PriceChangeID},
+			bson.M{"$set": bson.M{"oldprice": product.Price}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartPriceScheduler applies due price changes in the background every interval.
+func StartPriceScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := ApplyDuePriceChanges(ctx); err != nil {
+				log.Printf("Error while applying scheduled prices: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Deatsilence/go-stocket/database"
 	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/valuation"
 	"github.com/Deatsilence/go-stocket/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,13 +16,49 @@ import (
 )
 
 var transactionCollection *mongo.Collection = database.OpenCollection(database.Client, "transaction")
+var productCollection *mongo.Collection = database.OpenCollection(database.Client, "product")
 
 func CreateTransactionForProduct(userID string, productID string, processtype types.ProcessTypes, amount uint) (err error) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	var transaction models.Transaction
+
+	transaction.UserID = userID
+	transaction.ProductID = productID
+	transaction.ProcessType = strconv.Itoa(int(processtype))
+	transaction.Amount = amount
+
+	switch processtype {
+	case types.Add:
+		transaction.Direction = string(types.Inbound)
+	case types.Delete:
+		transaction.Direction = string(types.Outbound)
+	}
+
+	return RecordTransaction(transaction)
+}
 
+// CreateStockUpdateTransaction records an update of a product. A change of its stock is
+// recorded as a movement of the difference, so that replaying the movements in the
+// ledger gives the stock of the product.
+func CreateStockUpdateTransaction(userID string, productID string, oldStock uint, newStock uint) (err error) {
 	var transaction models.Transaction
 
+	transaction.UserID = userID
+	transaction.ProductID = productID
+	transaction.ProcessType = strconv.Itoa(int(types.Update))
+
+	if movement, ok := valuation.Adjustment(oldStock, newStock); ok {
+		transaction.Amount = movement.Quantity
+		transaction.Direction = string(types.Outbound)
+		if movement.Inbound {
+			transaction.Direction = string(types.Inbound)
+		}
+	}
+
+	return RecordTransaction(transaction)
+}
+
+// NewTransaction stamps the transaction with an id and the current time.
+func NewTransaction(transaction models.Transaction) models.Transaction {
 	processTime, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	if err != nil {
 		log.Printf("Error while parsing time: %v", err)
@@ -29,13 +66,17 @@ func CreateTransactionForProduct(userID string, productID string, processtype ty
 
 	transaction.ID = primitive.NewObjectID()
 	transaction.TransactionID = transaction.ID.Hex()
-	transaction.UserID = userID
-	transaction.ProductID = productID
-	transaction.ProcessType = strconv.Itoa(int(processtype))
-	transaction.Amount = amount
 	transaction.ProcessTime = processTime
 
-	_, insertErr := transactionCollection.InsertOne(ctx, transaction)
+	return transaction
+}
+
+// RecordTransaction stamps the transaction with an id and the current time and stores it in the ledger.
+func RecordTransaction(transaction models.Transaction) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, insertErr := transactionCollection.InsertOne(ctx, NewTransaction(transaction))
 
 	if insertErr != nil {
 		log.Printf("Error while inserting transaction: %v", insertErr)
@@ -44,7 +85,7 @@ func CreateTransactionForProduct(userID string, productID string, processtype ty
 	return insertErr
 }
 
-func UpdateFilter(product models.Product) bson.M {
+func UpdateFilter(product models.Product, hasStock bool) bson.M {
 	update := bson.M{}
 	if product.Name != nil {
 		update["name"] = product.Name
@@ -64,13 +105,32 @@ func UpdateFilter(product models.Product) bson.M {
 		log.Println("Category: ", product.Category)
 	}
 
-	update["stock"] = product.Stock
-	log.Println("Stock: ", product.Stock)
+	if product.BaseUnit != "" {
+		update["baseunit"] = product.BaseUnit
+	}
+	if product.Units != nil {
+		update["units"] = product.Units
+	}
+	if product.Location != nil {
+		update["location"] = product.Location
+	}
+	if product.Attributes != nil {
+		update["attributes"] = product.Attributes
+	}
+
+	// A stock of 0 is a valid value, so it is only set when the request sent one.
+	if hasStock {
+		update["stock"] = product.Stock
+		log.Println("Stock: ", product.Stock)
+	}
 
-	if product.Price >= 0.0 {
+	if product.Price != nil {
 		update["price"] = product.Price
 		log.Println("Price: ", product.Price)
 	}
+	if product.Prices != nil {
+		update["prices"] = product.Prices
+	}
 	update["updatedat"], _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 	return update
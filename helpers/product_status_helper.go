@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrProductArchived     = errors.New("archived products are read-only")
+	ErrProductDiscontinued = errors.New("discontinued products cannot be received")
+	ErrStatusTransition    = errors.New("the status change is not allowed")
+)
+
+// ProductStatus is the lifecycle status of a product. Products saved before statuses
+// existed are active.
+func ProductStatus(product models.Product) types.ProductStatusTypes {
+	status, err := types.ParseProductStatus(product.Status)
+	if err != nil {
+		return types.ProductActive
+	}
+	return status
+}
+
+// statusFilter matches the products with a status, counting a missing status as active.
+func statusFilter(statuses ...types.ProductStatusTypes) bson.M {
+	values := bson.A{}
+	for _, status := range statuses {
+		values = append(values, string(status))
+		if status == types.ProductActive {
+			values = append(values, "", nil)
+		}
+	}
+	return bson.M{"$in": values}
+}
+
+// CheckProductsWritable returns ErrProductArchived when any of the products is archived.
+// Products that do not exist are left for the caller to report.
+func CheckProductsWritable(ctx context.Context, productIDs ...string) error {
+	var product models.Product
+	err := productCollection.FindOne(ctx,
+		bson.M{"productid": bson.M{"$in": productIDs}, "status": string(types.ProductArchived)},
+		options.FindOne().SetProjection(bson.M{"productid": 1}),
+	).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: product %v is archived", ErrProductArchived, product.ProductID)
+}
+
+// CheckStockIncrease returns ErrProductDiscontinued when the stock of a discontinued
+// product would be raised, as discontinued products cannot be received. Products that do
+// not exist are left for the caller to report.
+func CheckStockIncrease(ctx context.Context, productID string, stock uint) error {
+	var product models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID},
+		options.FindOne().SetProjection(bson.M{"stock": 1, "status": 1}),
+	).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stockIncreaseRefused(product, stock) {
+		return fmt.Errorf("%w: product %v is discontinued", ErrProductDiscontinued, productID)
+	}
+	return nil
+}
+
+// stockIncreaseRefused reports whether the stock of a product cannot be raised to stock.
+func stockIncreaseRefused(product models.Product, stock uint) bool {
+	return stock > product.Stock && ProductStatus(product) == types.ProductDiscontinued
+}
+
+// ChangeProductStatus moves a product to another status when the transition is allowed
+// and records the change in the transaction ledger, in one database transaction.
+func ChangeProductStatus(ctx context.Context, userID string, productID string, to types.ProductStatusTypes) (models.Product, error) {
+	var product models.Product
+
+	err := database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		err := productCollection.FindOne(sessCtx, bson.M{"productid": productID}).Decode(&product)
+		if err != nil {
+			return err
+		}
+
+		from := ProductStatus(product)
+		if !from.CanTransition(to) {
+			allowed := []string{}
+			for _, status := range from.Transitions() {
+				allowed = append(allowed, string(status))
+			}
+			if len(allowed) == 0 {
+				return fmt.Errorf("%w: %s products cannot change status", ErrStatusTransition, from)
+			}
+			return fmt.Errorf("%w: a %s product can only become %s", ErrStatusTransition, from, strings.Join(allowed, " or "))
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		result, err := productCollection.UpdateOne(sessCtx,
+			bson.M{"productid": productID, "status": statusFilter(from)},
+			bson.M{"$set": bson.M{"status": string(to), "updatedat": updatedAt}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return fmt.Errorf("%w: the status changed in the meantime", ErrStatusTransition)
+		}
+
+		_, err = transactionCollection.InsertOne(sessCtx, NewTransaction(models.Transaction{
+			UserID:      userID,
+			ProductID:   productID,
+			ProcessType: strconv.Itoa(int(types.StatusChange)),
+			Amount:      product.Stock,
+			FromStatus:  string(from),
+			ToStatus:    string(to),
+		}))
+		if err != nil {
+			return err
+		}
+
+		product.Status = string(to)
+		product.UpdatedAt = updatedAt
+		return nil
+	})
+
+	return product, err
+}
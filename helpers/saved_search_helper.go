@@ -0,0 +1,192 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var savedSearchCollection *mongo.Collection = database.OpenCollection(database.Client, "savedsearch")
+
+// ProductListFilters are the product list parameters a saved search may hold.
+var ProductListFilters = []string{
+	"q", "prefix", "category", "status", "minprice", "maxprice", "currency", "minstock", "maxstock", "instock",
+	"createdatfrom", "createdatto", "updatedatfrom", "updatedatto",
+}
+
+// MaxDigestResults is the largest result set a digest keeps track of.
+const MaxDigestResults = 10000
+
+// digestListLimit is the number of added and removed products named in a digest email.
+const digestListLimit = 20
+
+// SavedSearchQuery turns a saved search into product list parameters for a page.
+func SavedSearchQuery(search models.SavedSearch, page int) url.Values {
+	query := url.Values{}
+	for key, value := range search.Filters {
+		query.Set(key, value)
+	}
+	if search.Sort != "" {
+		query.Set("sort", search.Sort)
+	}
+	if search.RecordPerPage > 0 {
+		query.Set("recordPerPage", strconv.Itoa(search.RecordPerPage))
+	}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	return query
+}
+
+// ValidateSavedSearch checks that the filters and sort of a saved search are ones the
+// product list understands.
+func ValidateSavedSearch(search models.SavedSearch) error {
+	for key := range search.Filters {
+		known := strings.HasPrefix(key, attributeParameter)
+		for _, filter := range ProductListFilters {
+			known = known || filter == key
+		}
+		if !known {
+			return fmt.Errorf("unknown filter %q, use one of %s or an attr. filter", key, strings.Join(ProductListFilters, ", "))
+		}
+	}
+
+	query := SavedSearchQuery(search, 0)
+	if _, err := ProductFilter(query); err != nil {
+		return err
+	}
+	_, err := ProductSort(query)
+	return err
+}
+
+// SendSavedSearchDigests emails the owners of saved searches with a digest whose results
+// changed since they were last checked. The first check only records the results.
+func SendSavedSearchDigests(ctx context.Context) error {
+	cursor, err := savedSearchCollection.Find(ctx, bson.M{"digest": true})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var searches []models.SavedSearch
+	if err = cursor.All(ctx, &searches); err != nil {
+		return err
+	}
+
+	for _, search := range searches {
+		if err := sendSavedSearchDigest(ctx, search); err != nil {
+			log.Printf("Error while checking saved search %v: %v", search.SavedSearchID, err)
+		}
+	}
+	return nil
+}
+
+func sendSavedSearchDigest(ctx context.Context, saved models.SavedSearch) error {
+	filter, err := ProductFilter(SavedSearchQuery(saved, 0))
+	if err != nil {
+		return err
+	}
+
+	count, err := productCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count > MaxDigestResults {
+		return fmt.Errorf("the search finds %d products, digests follow at most %d", count, MaxDigestResults)
+	}
+
+	cursor, err := productCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"productid": 1, "name": 1, "barcode": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(products))
+	byID := map[string]models.Product{}
+	for _, product := range products {
+		ids = append(ids, product.ProductID)
+		byID[product.ProductID] = product
+	}
+	addedIDs, removed := search.Changes(saved.ResultIDs, ids)
+	var added []models.Product
+	for _, id := range addedIDs {
+		added = append(added, byID[id])
+	}
+	sort.Strings(ids)
+
+	checkedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	firstCheck := saved.CheckedAt.IsZero()
+
+	if !firstCheck && (len(added) > 0 || len(removed) > 0) && saved.OwnerEmail != "" {
+		if err := SendMail(saved.OwnerEmail, "Saved search "+*saved.Name+" changed", digestBody(saved, added, removed)); err != nil {
+			return err
+		}
+	}
+
+	_, err = savedSearchCollection.UpdateOne(ctx,
+		bson.M{"savedsearchid": saved.SavedSearchID},
+		bson.M{"$set": bson.M{"resultids": ids, "checkedat": checkedAt}},
+	)
+	return err
+}
+
+func digestBody(search models.SavedSearch, added []models.Product, removed []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your saved search %q changed since %s: %d products added, %d removed.\n",
+		*search.Name, search.CheckedAt.Format("2006-01-02 15:04 MST"), len(added), len(removed))
+
+	if len(added) > 0 {
+		b.WriteString("\nAdded:\n")
+		for i, product := range added {
+			if i == digestListLimit {
+				fmt.Fprintf(&b, "- and %d more\n", len(added)-digestListLimit)
+				break
+			}
+			fmt.Fprintf(&b, "- %s (%s)\n", stringValue(product.Name), product.Barcode)
+		}
+	}
+	if len(removed) > 0 {
+		b.WriteString("\nNo longer found:\n")
+		for i, id := range removed {
+			if i == digestListLimit {
+				fmt.Fprintf(&b, "- and %d more\n", len(removed)-digestListLimit)
+				break
+			}
+			fmt.Fprintf(&b, "- product %s\n", id)
+		}
+	}
+	return b.String()
+}
+
+// StartSavedSearchDigests checks the saved searches with a digest in the background every interval.
+func StartSavedSearchDigests(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := SendSavedSearchDigests(ctx); err != nil {
+				log.Printf("Error while sending saved search digests: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
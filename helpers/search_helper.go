@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/search"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// FuzzyCandidateLimit caps the products scored in memory when the text index finds nothing.
+	FuzzyCandidateLimit = 2000
+	// FuzzyThreshold is the lowest score a fuzzy match needs to be returned.
+	FuzzyThreshold = 0.5
+	// SnippetWidth is the length of the description snippet of a hit.
+	SnippetWidth = 120
+)
+
+var ErrEmptyQuery = errors.New("the query has no words to search for")
+
+// searchWeights rank a match in the name above one in the barcode or the description.
+var searchWeights = map[string]float64{"name": 10, "barcode": 5, "description": 1}
+
+// EnsureSearchIndex creates the text index the product search runs on. Words are not
+// stemmed so that names in any language and barcodes are matched as they are written.
+func EnsureSearchIndex(ctx context.Context) error {
+	weights := bson.D{}
+	keys := bson.D{}
+	for _, field := range []string{"name", "barcode", "description"} {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: int32(searchWeights[field])})
+	}
+
+	_, err := productCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetName("product_search").
+			SetWeights(weights).
+			SetDefaultLanguage("none"),
+	})
+	return err
+}
+
+// SearchHit is a product found by a search with its relevance and the matching parts of
+// its fields, escaped for HTML with matches wrapped in <em> tags.
+type SearchHit struct {
+	Product    models.Product    `json:"product"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchPage is one page of search hits. Mode is "text" when the text index found the
+// hits and "fuzzy" when they were found by tolerating typos.
+type SearchPage struct {
+	Query       string      `json:"query"`
+	Mode        string      `json:"mode"`
+	Items       []SearchHit `json:"items"`
+	TotalCount  int64       `json:"totalCount"`
+	TotalPages  int64       `json:"totalPages"`
+	CurrentPage int         `json:"currentPage"`
+}
+
+// SearchProducts ranks products by the text index and falls back to fuzzy matching when
+// nothing matches the words exactly, as happens with typos.
+func SearchProducts(ctx context.Context, query string, page int, recordPerPage int) (SearchPage, error) {
+	result := SearchPage{Query: query, Mode: "text", Items: []SearchHit{}, CurrentPage: page}
+
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return result, ErrEmptyQuery
+	}
+
+	// Only the words go to $text so that quotes and minus signs in the query cannot turn
+	// into phrase or negation operators.
+	filter := bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}, "status": bson.M{"$ne": string(types.ProductDraft)}}
+	total, err := productCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+
+	if total == 0 {
+		hits, err := fuzzySearch(ctx, terms)
+		if err != nil {
+			return result, err
+		}
+		result.Mode = "fuzzy"
+		total = int64(len(hits))
+		start := minInt64(int64((page-1)*recordPerPage), total)
+		end := minInt64(start+int64(recordPerPage), total)
+		result.Items = hits[start:end]
+	} else {
+		opts := options.Find().
+			SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+			SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}).
+			SetSkip(int64((page - 1) * recordPerPage)).
+			SetLimit(int64(recordPerPage))
+
+		cursor, err := productCollection.Find(ctx, filter, opts)
+		if err != nil {
+			return result, err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var hit SearchHit
+			var scored struct {
+				Score float64 `bson:"score"`
+			}
+			if err := cursor.Decode(&hit.Product); err != nil {
+				return result, err
+			}
+			if err := cursor.Decode(&scored); err != nil {
+				return result, err
+			}
+			hit.Score = scored.Score
+			hit.Highlights = highlights(hit.Product, terms)
+			result.Items = append(result.Items, hit)
+		}
+		if err := cursor.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	result.TotalCount = total
+	result.TotalPages = (total + int64(recordPerPage) - 1) / int64(recordPerPage)
+	return result, nil
+}
+
+// fuzzySearch scores the products sharing a pair of letters with the terms and returns
+// those scoring at least FuzzyThreshold, best first.
+func fuzzySearch(ctx context.Context, terms []string) ([]SearchHit, error) {
+	hits := []SearchHit{}
+
+	fragments := search.Fragments(terms)
+	if len(fragments) == 0 {
+		return hits, nil
+	}
+	for i, fragment := range fragments {
+		fragments[i] = regexp.QuoteMeta(fragment)
+	}
+	pattern := bson.M{"$regex": strings.Join(fragments, "|"), "$options": "i"}
+
+	filter := bson.M{
+		"$or":    []bson.M{{"name": pattern}, {"barcode": pattern}, {"description": pattern}},
+		"status": bson.M{"$ne": string(types.ProductDraft)},
+	}
+	cursor, err := productCollection.Find(ctx, filter, options.Find().SetLimit(FuzzyCandidateLimit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		score := search.Score(terms, searchFields(product)...)
+		if score < FuzzyThreshold {
+			continue
+		}
+		hits = append(hits, SearchHit{Product: product, Score: score, Highlights: highlights(product, terms)})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits, nil
+}
+
+func searchFields(product models.Product) []search.Field {
+	return []search.Field{
+		{Name: "name", Text: stringValue(product.Name), Weight: searchWeights["name"]},
+		{Name: "barcode", Text: product.Barcode, Weight: searchWeights["barcode"]},
+		{Name: "description", Text: stringValue(product.Description), Weight: searchWeights["description"]},
+	}
+}
+
+func highlights(product models.Product, terms []string) map[string]string {
+	result := map[string]string{}
+	for _, field := range searchFields(product) {
+		width := 0
+		if field.Name == "description" {
+			width = SnippetWidth
+		}
+		if snippet, ok := search.Highlight(field.Text, terms, width); ok {
+			result[field.Name] = snippet
+		}
+	}
+	return result
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,310 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/cache"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// StatsCacheTTL is how long a computed overview is served before it is computed again.
+	StatsCacheTTL = 30 * time.Second
+	// DefaultTopMovers is the number of top movers listed when a request does not say.
+	DefaultTopMovers = 10
+	// MaxTopMovers is the largest number of top movers a request can ask for.
+	MaxTopMovers = 50
+	// MaxLowStock is the largest low stock threshold a request can ask for. Every
+	// threshold is cached on its own, so they are kept to a small range.
+	MaxLowStock = 100
+)
+
+var statsCache = cache.NewTTL(StatsCacheTTL)
+
+// TopMover is a product with the quantities it moved in and out over a period.
+type TopMover struct {
+	ProductID    string `json:"productid"`
+	Barcode      string `json:"barcode"`
+	Name         string `json:"name"`
+	Inbound      uint   `json:"inbound"`
+	Outbound     uint   `json:"outbound"`
+	Transactions int64  `json:"transactions"`
+}
+
+// StatsOverview sums up the inventory for the dashboard. Archived products are left out,
+// and bundles count as SKUs but not towards units or stock levels, as their stock is
+// that of their components. The stock value is the stock at list price, converted to the
+// base currency; prices in currencies without an exchange rate are listed as unconverted.
+type StatsOverview struct {
+	GeneratedAt       time.Time       `json:"generatedat"`
+	TotalSKUs         int64           `json:"totalskus"`
+	TotalUnits        int64           `json:"totalunits"`
+	StockValue        types.Money     `json:"stockvalue"`
+	Unconverted       []types.Money   `json:"unconverted,omitempty"`
+	Categories        []CategoryFacet `json:"categories"`
+	OutOfStock        int64           `json:"outofstock"`
+	LowStock          int64           `json:"lowstock"`
+	LowStockThreshold uint            `json:"lowstockthreshold"`
+	TopMovers7Days    []TopMover      `json:"topmovers7d"`
+	TopMovers30Days   []TopMover      `json:"topmovers30d"`
+}
+
+// StatsOverviewFor returns the overview for a low stock threshold and a number of top
+// movers, from the cache when it was computed less than StatsCacheTTL ago.
+func StatsOverviewFor(ctx context.Context, lowStock uint, top int) (StatsOverview, error) {
+	key := fmt.Sprintf("%d/%d", lowStock, top)
+	if cached, ok := statsCache.Get(key); ok {
+		return cached.(StatsOverview), nil
+	}
+
+	overview, err := computeStatsOverview(ctx, lowStock, top)
+	if err != nil {
+		return overview, err
+	}
+	statsCache.Set(key, overview)
+	return overview, nil
+}
+
+func computeStatsOverview(ctx context.Context, lowStock uint, top int) (StatsOverview, error) {
+	overview := StatsOverview{
+		GeneratedAt:       time.Now().UTC(),
+		StockValue:        types.MoneyFromRat(new(big.Rat), BASE_CURRENCY, 2),
+		Categories:        []CategoryFacet{},
+		LowStockThreshold: lowStock,
+	}
+
+	if err := productStats(ctx, &overview, lowStock); err != nil {
+		return overview, err
+	}
+
+	now := overview.GeneratedAt
+	movers, err := topMovers(ctx, now.AddDate(0, 0, -7), now.AddDate(0, 0, -30), top)
+	if err != nil {
+		return overview, err
+	}
+	overview.TopMovers7Days = movers[0]
+	overview.TopMovers30Days = movers[1]
+	return overview, nil
+}
+
+// productStats counts the products and values their stock in one $facet aggregation.
+func productStats(ctx context.Context, overview *StatsOverview, lowStock uint) error {
+	stocked := bson.D{{Key: "$match", Value: bson.M{"kind": bson.M{"$ne": string(types.Bundle)}}}}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"status": bson.M{"$ne": string(types.ProductArchived)}}}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "totals", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: nil},
+					{Key: "skus", Value: bson.D{{Key: "$sum", Value: 1}}},
+					{Key: "units", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+						bson.D{{Key: "$eq", Value: bson.A{"$kind", string(types.Bundle)}}}, 0, "$stock",
+					}}}}}},
+				}}},
+			}},
+			{Key: "categories", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$category"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+			}},
+			{Key: "stock", Value: bson.A{
+				stocked,
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: nil},
+					{Key: "outofstock", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+						bson.D{{Key: "$lte", Value: bson.A{"$stock", 0}}}, 1, 0,
+					}}}}}},
+					{Key: "low", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+						bson.D{{Key: "$and", Value: bson.A{
+							bson.D{{Key: "$gt", Value: bson.A{"$stock", 0}}},
+							bson.D{{Key: "$lte", Value: bson.A{"$stock", lowStock}}},
+						}}}, 1, 0,
+					}}}}}},
+				}}},
+			}},
+			{Key: "values", Value: bson.A{
+				stocked,
+				bson.D{{Key: "$match", Value: bson.M{"stock": bson.M{"$gt": 0}, "price": bson.M{"$ne": nil}}}},
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$price.currency", ""}}}},
+					{Key: "value", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$multiply", Value: bson.A{
+						bson.D{{Key: "$toDecimal", Value: priceAmount}}, "$stock",
+					}}}}}},
+				}}},
+			}},
+		}}},
+	}
+
+	cursor, err := productCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Totals []struct {
+			SKUs  int64 `bson:"skus"`
+			Units int64 `bson:"units"`
+		} `bson:"totals"`
+		Categories []struct {
+			ID    *int  `bson:"_id"`
+			Count int64 `bson:"count"`
+		} `bson:"categories"`
+		Stock []struct {
+			OutOfStock int64 `bson:"outofstock"`
+			Low        int64 `bson:"low"`
+		} `bson:"stock"`
+		Values []struct {
+			Currency string               `bson:"_id"`
+			Value    primitive.Decimal128 `bson:"value"`
+		} `bson:"values"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	result := results[0]
+
+	if len(result.Totals) > 0 {
+		overview.TotalSKUs = result.Totals[0].SKUs
+		overview.TotalUnits = result.Totals[0].Units
+	}
+	for _, category := range result.Categories {
+		if category.ID == nil {
+			continue
+		}
+		overview.Categories = append(overview.Categories, CategoryFacet{
+			Category: *category.ID,
+			Name:     types.CategoryTypes(*category.ID).String(),
+			Count:    category.Count,
+		})
+	}
+	sort.Slice(overview.Categories, func(i, j int) bool { return overview.Categories[i].Category < overview.Categories[j].Category })
+	if len(result.Stock) > 0 {
+		overview.OutOfStock = result.Stock[0].OutOfStock
+		overview.LowStock = result.Stock[0].Low
+	}
+
+	total := new(big.Rat)
+	for _, value := range result.Values {
+		converted, err := ConvertToBase(ctx, types.Money{Amount: value.Value, Currency: value.Currency})
+		if errors.Is(err, ErrNoExchangeRate) {
+			overview.Unconverted = append(overview.Unconverted, types.Money{Amount: value.Value, Currency: value.Currency})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		total.Add(total, converted.Rat())
+	}
+	sort.Slice(overview.Unconverted, func(i, j int) bool { return overview.Unconverted[i].Currency < overview.Unconverted[j].Currency })
+	overview.StockValue = types.MoneyFromRat(total, BASE_CURRENCY, 2)
+	return nil
+}
+
+// topMovers ranks the products by the quantity they issued since each of the two times,
+// then by the quantity they received. Both rankings come from one $facet aggregation
+// over the ledger since the earlier time.
+func topMovers(ctx context.Context, recent time.Time, earlier time.Time, top int) ([2][]TopMover, error) {
+	movers := [2][]TopMover{{}, {}}
+
+	ranking := func(since time.Time) bson.A {
+		return bson.A{
+			bson.D{{Key: "$match", Value: bson.M{"processtime": bson.M{"$gte": since}}}},
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$productid"},
+				{Key: "inbound", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{"$direction", string(types.Inbound)}}}, "$amount", 0,
+				}}}}}},
+				{Key: "outbound", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{"$direction", string(types.Outbound)}}}, "$amount", 0,
+				}}}}}},
+				{Key: "transactions", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "outbound", Value: -1}, {Key: "inbound", Value: -1}, {Key: "_id", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: top}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"processtime": bson.M{"$gte": earlier},
+			"direction":   bson.M{"$in": []string{string(types.Inbound), string(types.Outbound)}},
+		}}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "recent", Value: ranking(recent)},
+			{Key: "earlier", Value: ranking(earlier)},
+		}}},
+	}
+
+	cursor, err := transactionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return movers, err
+	}
+	defer cursor.Close(ctx)
+
+	type mover struct {
+		ProductID    string `bson:"_id"`
+		Inbound      int64  `bson:"inbound"`
+		Outbound     int64  `bson:"outbound"`
+		Transactions int64  `bson:"transactions"`
+	}
+	var results []struct {
+		Recent  []mover `bson:"recent"`
+		Earlier []mover `bson:"earlier"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return movers, err
+	}
+	if len(results) == 0 {
+		return movers, nil
+	}
+
+	ids := []string{}
+	for _, ranked := range [][]mover{results[0].Recent, results[0].Earlier} {
+		for _, m := range ranked {
+			ids = append(ids, m.ProductID)
+		}
+	}
+	products := map[string]models.Product{}
+	if len(ids) > 0 {
+		cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"productid": 1, "barcode": 1, "name": 1}))
+		if err != nil {
+			return movers, err
+		}
+		var found []models.Product
+		if err = cursor.All(ctx, &found); err != nil {
+			return movers, err
+		}
+		for _, product := range found {
+			products[product.ProductID] = product
+		}
+	}
+
+	for i, ranked := range [][]mover{results[0].Recent, results[0].Earlier} {
+		for _, m := range ranked {
+			product := products[m.ProductID]
+			movers[i] = append(movers[i], TopMover{
+				ProductID:    m.ProductID,
+				Barcode:      product.Barcode,
+				Name:         stringValue(product.Name),
+				Inbound:      uint(m.Inbound),
+				Outbound:     uint(m.Outbound),
+				Transactions: m.Transactions,
+			})
+		}
+	}
+	return movers, nil
+}
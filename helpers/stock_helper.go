@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// MoveStock applies a receive or issue to the stock of a product and records the base
+// quantity in the transaction ledger, in one database transaction. The unit cost of a receive is stored per base unit
+// in the base currency.
+func MoveStock(ctx context.Context, userID string, product models.Product, unit models.ProductUnit, movement models.StockMovement, processtype types.ProcessTypes) error {
+	amount, err := ToBaseQuantity(unit, movement.Quantity)
+	if err != nil {
+		return err
+	}
+
+	var unitCost *types.Money
+	if processtype == types.Receive && movement.UnitCost != nil {
+		if err := NormalizePrice(movement.UnitCost); err != nil {
+			return err
+		}
+		baseCost, err := ConvertToBase(ctx, *movement.UnitCost)
+		if err != nil {
+			return err
+		}
+		baseCost = baseCost.Mul(big.NewRat(1, int64(unit.Factor)))
+		unitCost = &baseCost
+	}
+
+	filter := bson.M{"productid": product.ProductID}
+	change := int64(amount)
+	direction := types.Inbound
+
+	if processtype == types.Issue {
+		filter["stock"] = bson.M{"$gte": amount}
+		change = -change
+		direction = types.Outbound
+	}
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	update := bson.M{
+		"$inc": bson.M{"stock": change},
+		"$set": bson.M{"updatedat": updatedAt},
+	}
+
+	// The stock and its ledger entry are written together so that a failed entry does not
+	// leave the stock changed for a retry to change again.
+	return database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		result, err := productCollection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrInsufficientStock
+		}
+
+		_, err = transactionCollection.InsertOne(sessCtx, NewTransaction(models.Transaction{
+			UserID:       userID,
+			ProductID:    product.ProductID,
+			ProcessType:  strconv.Itoa(int(processtype)),
+			Amount:       amount,
+			Direction:    string(direction),
+			Unit:         unit.Name,
+			UnitQuantity: movement.Quantity,
+			UnitCost:     unitCost,
+		}))
+		return err
+	})
+}
@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/units"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultBaseUnit is used for products that are created without a base unit.
+const DefaultBaseUnit = "piece"
+
+var (
+	ErrQuantityTooLarge = units.ErrQuantityTooLarge
+	ErrBarcodeInUse     = errors.New("barcode is already in use")
+	ErrInvalidUnits     = errors.New("invalid units")
+)
+
+// ValidateUnits checks that the pack units of a product have unique names and barcodes.
+func ValidateUnits(product models.Product) error {
+	names := map[string]bool{product.BaseUnit: true}
+	barcodes := map[string]bool{product.Barcode: true}
+
+	for _, unit := range product.Units {
+		if names[unit.Name] {
+			return fmt.Errorf("unit %q is defined more than once", unit.Name)
+		}
+		names[unit.Name] = true
+
+		if unit.Barcode == "" {
+			continue
+		}
+		if barcodes[unit.Barcode] {
+			return fmt.Errorf("barcode %q is used more than once", unit.Barcode)
+		}
+		barcodes[unit.Barcode] = true
+	}
+	return nil
+}
+
+// ResolveUnit finds the unit of a stock movement by its name or, when no name is given,
+// by a scanned pack barcode. An empty movement resolves to the base unit.
+func ResolveUnit(product models.Product, movement models.StockMovement) (models.ProductUnit, error) {
+	return units.Resolve(product, movement)
+}
+
+// ToBaseQuantity converts a quantity in the given unit to the base unit of the product.
+func ToBaseQuantity(unit models.ProductUnit, quantity uint) (uint, error) {
+	return units.ToBase(unit, quantity)
+}
+
+// BarcodeInUse reports whether a barcode is already used by another product, either as
+// its own barcode or as the barcode of one of its pack units.
+func BarcodeInUse(ctx context.Context, barcode string, exceptProductID string) (bool, error) {
+	filter := bson.M{"$or": []bson.M{{"barcode": barcode}, {"units.barcode": barcode}}}
+	if exceptProductID != "" {
+		filter["productid"] = bson.M{"$ne": exceptProductID}
+	}
+
+	count, err := productCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ValidatePartialUnits checks the barcode, base unit and pack units a partial update
+// sets against each other, the fields it leaves as stored and the other products.
+func ValidatePartialUnits(ctx context.Context, productID string, product models.Product) error {
+	if product.Barcode == "" && product.BaseUnit == "" && product.Units == nil {
+		return nil
+	}
+
+	var stored models.Product
+	err := productCollection.FindOne(ctx, bson.M{"productid": productID},
+		options.FindOne().SetProjection(bson.M{"barcode": 1, "baseunit": 1, "units": 1})).Decode(&stored)
+	if err != nil {
+		return err
+	}
+	if product.Barcode != "" {
+		stored.Barcode = product.Barcode
+	}
+	if product.BaseUnit != "" {
+		stored.BaseUnit = product.BaseUnit
+	}
+	if stored.BaseUnit == "" {
+		stored.BaseUnit = DefaultBaseUnit
+	}
+	if product.Units != nil {
+		stored.Units = product.Units
+	}
+	if err := ValidateUnits(stored); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidUnits, err)
+	}
+
+	barcodes := []string{product.Barcode}
+	for _, unit := range product.Units {
+		barcodes = append(barcodes, unit.Barcode)
+	}
+	for _, barcode := range barcodes {
+		if barcode == "" {
+			continue
+		}
+		inUse, err := BarcodeInUse(ctx, barcode, productID)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return fmt.Errorf("%w: %s", ErrBarcodeInUse, barcode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"github.com/Deatsilence/go-stocket/pkg/barcode"
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator with stocket's custom tags registered:
+//
+//	barcode: the value is an EAN-8, EAN-13, UPC-A or GTIN-14 with a valid check digit, or a Code128 payload
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+
+	validate.RegisterValidation("barcode", func(fl validator.FieldLevel) bool {
+		return barcode.Validate(fl.Field().String()) == nil
+	})
+
+	return validate
+}
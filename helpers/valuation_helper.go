@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/valuation"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ledgerOrder replays movements in the order they were recorded. Process times only have
+// second precision, so the id breaks ties between movements of the same second.
+var ledgerOrder = bson.D{{Key: "processtime", Value: 1}, {Key: "_id", Value: 1}}
+
+// ValuationLine is one row of the inventory valuation report, either for a product or,
+// with an empty ProductID, for a whole category.
+type ValuationLine struct {
+	ProductID        string      `json:"productid,omitempty"`
+	Barcode          string      `json:"barcode,omitempty"`
+	Name             string      `json:"name,omitempty"`
+	Category         int         `json:"category"`
+	OpeningQuantity  uint        `json:"openingquantity"`
+	OpeningValue     types.Money `json:"openingvalue"`
+	ReceivedQuantity uint        `json:"receivedquantity"`
+	ReceivedValue    types.Money `json:"receivedvalue"`
+	IssuedQuantity   uint        `json:"issuedquantity"`
+	IssuedCost       types.Money `json:"issuedcost"`
+	ClosingQuantity  uint        `json:"closingquantity"`
+	ClosingValue     types.Money `json:"closingvalue"`
+}
+
+// ValuationReport holds the product lines, the category subtotals and the grand total.
+type ValuationReport struct {
+	Method     valuation.Method `json:"method"`
+	Currency   string           `json:"currency"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Products   []ValuationLine  `json:"products"`
+	Categories []ValuationLine  `json:"categories"`
+	Total      ValuationLine    `json:"total"`
+}
+
+type valuationTotals struct {
+	openingQuantity, receivedQuantity, issuedQuantity, closingQuantity uint
+	openingValue, receivedValue, issuedCost, closingValue              *big.Rat
+}
+
+func newValuationTotals() *valuationTotals {
+	return &valuationTotals{
+		openingValue:  new(big.Rat),
+		receivedValue: new(big.Rat),
+		issuedCost:    new(big.Rat),
+		closingValue:  new(big.Rat),
+	}
+}
+
+func (t *valuationTotals) add(result valuation.Result) {
+	t.openingQuantity += result.OpeningQuantity
+	t.receivedQuantity += result.ReceivedQuantity
+	t.issuedQuantity += result.IssuedQuantity
+	t.closingQuantity += result.ClosingQuantity
+	t.openingValue.Add(t.openingValue, result.OpeningValue)
+	t.receivedValue.Add(t.receivedValue, result.ReceivedValue)
+	t.issuedCost.Add(t.issuedCost, result.IssuedCost)
+	t.closingValue.Add(t.closingValue, result.ClosingValue)
+}
+
+func (t *valuationTotals) line() ValuationLine {
+	money := func(r *big.Rat) types.Money { return types.MoneyFromRat(r, BASE_CURRENCY, 2) }
+	return ValuationLine{
+		OpeningQuantity:  t.openingQuantity,
+		OpeningValue:     money(t.openingValue),
+		ReceivedQuantity: t.receivedQuantity,
+		ReceivedValue:    money(t.receivedValue),
+		IssuedQuantity:   t.issuedQuantity,
+		IssuedCost:       money(t.issuedCost),
+		ClosingQuantity:  t.closingQuantity,
+		ClosingValue:     money(t.closingValue),
+	}
+}
+
+// ValueInventory values the products in the filter over from..to by replaying their stock
+// movements from the transaction ledger. Inbound movements without a cost count as zero.
+func ValueInventory(ctx context.Context, productFilter bson.M, method valuation.Method, from time.Time, to time.Time) (ValuationReport, error) {
+	report := ValuationReport{Method: method, Currency: BASE_CURRENCY, From: from, To: to}
+
+	cursor, err := productCollection.Find(ctx, productFilter)
+	if err != nil {
+		return report, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return report, err
+	}
+
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+
+	transactionFilter := bson.M{
+		"productid": bson.M{"$in": productIDs},
+		"direction": bson.M{"$in": []string{string(types.Inbound), string(types.Outbound)}},
+	}
+	if !to.IsZero() {
+		transactionFilter["processtime"] = bson.M{"$lte": to}
+	}
+
+	cursor, err = transactionCollection.Find(ctx, transactionFilter, options.Find().SetSort(ledgerOrder))
+	if err != nil {
+		return report, err
+	}
+	var transactions []models.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return report, err
+	}
+
+	movements := map[string][]valuation.Movement{}
+	for _, transaction := range transactions {
+		movement := valuation.Movement{
+			Time:     transaction.ProcessTime,
+			Inbound:  transaction.Direction == string(types.Inbound),
+			Quantity: transaction.Amount,
+		}
+		if transaction.UnitCost != nil {
+			movement.UnitCost = transaction.UnitCost.Rat()
+		}
+		movements[transaction.ProductID] = append(movements[transaction.ProductID], movement)
+	}
+
+	categoryTotals := map[int]*valuationTotals{}
+	grandTotal := newValuationTotals()
+
+	for _, product := range products {
+		result := valuation.Value(movements[product.ProductID], method, from, to)
+
+		lineTotals := newValuationTotals()
+		lineTotals.add(result)
+		line := lineTotals.line()
+		line.ProductID = product.ProductID
+		line.Barcode = product.Barcode
+		if product.Name != nil {
+			line.Name = *product.Name
+		}
+		if product.Category != nil {
+			line.Category = *product.Category
+		}
+		report.Products = append(report.Products, line)
+
+		if categoryTotals[line.Category] == nil {
+			categoryTotals[line.Category] = newValuationTotals()
+		}
+		categoryTotals[line.Category].add(result)
+		grandTotal.add(result)
+	}
+
+	for category, totals := range categoryTotals {
+		line := totals.line()
+		line.Category = category
+		report.Categories = append(report.Categories, line)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Category < report.Categories[j].Category })
+
+	report.Total = grandTotal.line()
+	return report, nil
+}
@@ -0,0 +1,248 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/valuation"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var workOrderCollection *mongo.Collection = database.OpenCollection(database.Client, "workorder")
+
+var ErrWorkOrderStatus = errors.New("the work order is not in a status that allows this")
+
+// ValidateWorkOrder checks that the produced product and the components exist, that none
+// of them is a bundle and that the produced product is not also consumed.
+func ValidateWorkOrder(ctx context.Context, order models.WorkOrder) error {
+	ids := []string{order.ProductID}
+	seen := map[string]bool{order.ProductID: true}
+	for _, component := range order.Components {
+		if component.ProductID == order.ProductID {
+			return errors.New("the produced product cannot also be a component")
+		}
+		if seen[component.ProductID] {
+			return fmt.Errorf("component %v is listed twice", component.ProductID)
+		}
+		seen[component.ProductID] = true
+		ids = append(ids, component.ProductID)
+	}
+
+	cursor, err := productCollection.Find(ctx, bson.M{"productid": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"productid": 1, "kind": 1, "status": 1}))
+	if err != nil {
+		return err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, product := range products {
+		if product.Kind == string(types.Bundle) {
+			return fmt.Errorf("product %v is a bundle, bundles have no stock of their own", product.ProductID)
+		}
+		if err := checkWorkOrderStatus(order, product); err != nil {
+			return err
+		}
+		found[product.ProductID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("product %v not found", id)
+		}
+	}
+	return nil
+}
+
+// checkWorkOrderStatus refuses archived products and the production of discontinued ones.
+func checkWorkOrderStatus(order models.WorkOrder, product models.Product) error {
+	switch ProductStatus(product) {
+	case types.ProductArchived:
+		return fmt.Errorf("%w: product %v is archived", ErrProductArchived, product.ProductID)
+	case types.ProductDiscontinued:
+		if product.ProductID == order.ProductID {
+			return fmt.Errorf("%w: product %v is discontinued", ErrProductDiscontinued, product.ProductID)
+		}
+	}
+	return nil
+}
+
+// componentCosts costs the quantity each component of a work order consumes as its next
+// issue, by replaying its movements in the ledger with the given method.
+func componentCosts(ctx context.Context, order models.WorkOrder, method valuation.Method) ([]*big.Rat, error) {
+	ids := make([]string, 0, len(order.Components))
+	for _, component := range order.Components {
+		ids = append(ids, component.ProductID)
+	}
+
+	cursor, err := transactionCollection.Find(ctx, bson.M{
+		"productid": bson.M{"$in": ids},
+		"direction": bson.M{"$in": []string{string(types.Inbound), string(types.Outbound)}},
+	}, options.Find().SetSort(ledgerOrder))
+	if err != nil {
+		return nil, err
+	}
+	var transactions []models.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	movements := map[string][]valuation.Movement{}
+	for _, transaction := range transactions {
+		movement := valuation.Movement{
+			Time:     transaction.ProcessTime,
+			Inbound:  transaction.Direction == string(types.Inbound),
+			Quantity: transaction.Amount,
+		}
+		if transaction.UnitCost != nil {
+			movement.UnitCost = transaction.UnitCost.Rat()
+		}
+		movements[transaction.ProductID] = append(movements[transaction.ProductID], movement)
+	}
+
+	costs := make([]*big.Rat, 0, len(order.Components))
+	for _, component := range order.Components {
+		costs = append(costs, valuation.IssueCost(movements[component.ProductID], method, component.Quantity))
+	}
+	return costs, nil
+}
+
+// CompleteWorkOrder consumes the components of a work order in progress and produces its
+// product, all in one database transaction. The consumed components are costed with the
+// given method and their total is rolled up into the unit cost of the produced entry.
+// The ledger entries share the work order id as their batch id.
+func CompleteWorkOrder(ctx context.Context, workOrderID string, userID string, method valuation.Method) (models.WorkOrder, error) {
+	var order models.WorkOrder
+
+	err := database.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		err := workOrderCollection.FindOne(sessCtx, bson.M{"workorderid": workOrderID}).Decode(&order)
+		if err != nil {
+			return err
+		}
+		if order.Status != string(types.WorkOrderInProgress) {
+			return ErrWorkOrderStatus
+		}
+
+		ids := []string{order.ProductID}
+		for _, component := range order.Components {
+			ids = append(ids, component.ProductID)
+		}
+		cursor, err := productCollection.Find(sessCtx, bson.M{"productid": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"productid": 1, "status": 1}))
+		if err != nil {
+			return err
+		}
+		var products []models.Product
+		if err = cursor.All(sessCtx, &products); err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := checkWorkOrderStatus(order, product); err != nil {
+				return err
+			}
+		}
+
+		costs, err := componentCosts(sessCtx, order, method)
+		if err != nil {
+			return err
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		var ledger []interface{}
+		total := new(big.Rat)
+
+		for i, component := range order.Components {
+			result, err := productCollection.UpdateOne(sessCtx,
+				bson.M{"productid": component.ProductID, "stock": bson.M{"$gte": component.Quantity}},
+				bson.M{
+					"$inc": bson.M{"stock": -int64(component.Quantity)},
+					"$set": bson.M{"updatedat": updatedAt},
+				},
+			)
+			if err != nil {
+				return err
+			}
+			if result.MatchedCount == 0 {
+				return fmt.Errorf("%w for component %v", ErrInsufficientStock, component.ProductID)
+			}
+
+			cost := types.MoneyFromRat(costs[i], BASE_CURRENCY, 2)
+			order.Components[i].Cost = &cost
+			total.Add(total, costs[i])
+
+			ledger = append(ledger, NewTransaction(models.Transaction{
+				UserID:      userID,
+				ProductID:   component.ProductID,
+				ProcessType: strconv.Itoa(int(types.Consume)),
+				Amount:      component.Quantity,
+				Direction:   string(types.Outbound),
+				BatchID:     order.WorkOrderID,
+			}))
+		}
+
+		unitCost := types.MoneyFromRat(new(big.Rat).Quo(total, new(big.Rat).SetInt64(int64(order.Quantity))), BASE_CURRENCY, types.MoneyScale)
+		totalCost := types.MoneyFromRat(total, BASE_CURRENCY, 2)
+
+		result, err := productCollection.UpdateOne(sessCtx,
+			bson.M{"productid": order.ProductID},
+			bson.M{
+				"$inc": bson.M{"stock": int64(order.Quantity)},
+				"$set": bson.M{"updatedat": updatedAt},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return fmt.Errorf("product %v not found", order.ProductID)
+		}
+
+		ledger = append(ledger, NewTransaction(models.Transaction{
+			UserID:      userID,
+			ProductID:   order.ProductID,
+			ProcessType: strconv.Itoa(int(types.Produce)),
+			Amount:      order.Quantity,
+			Direction:   string(types.Inbound),
+			UnitCost:    &unitCost,
+			BatchID:     order.WorkOrderID,
+		}))
+		if _, err := transactionCollection.InsertMany(sessCtx, ledger); err != nil {
+			return err
+		}
+
+		order.Status = string(types.WorkOrderCompleted)
+		order.Method = string(method)
+		order.UnitCost = &unitCost
+		order.TotalCost = &totalCost
+		order.CompletedBy = userID
+		order.CompletedAt = updatedAt
+		order.UpdatedAt = updatedAt
+
+		_, err = workOrderCollection.UpdateOne(sessCtx,
+			bson.M{"workorderid": workOrderID, "status": string(types.WorkOrderInProgress)},
+			bson.M{"$set": bson.M{
+				"status":      order.Status,
+				"components":  order.Components,
+				"method":      order.Method,
+				"unitcost":    order.UnitCost,
+				"totalcost":   order.TotalCost,
+				"completedby": order.CompletedBy,
+				"completedat": order.CompletedAt,
+				"updatedat":   order.UpdatedAt,
+			}},
+		)
+		return err
+	})
+
+	return order, err
+}
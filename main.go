@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	helper "github.com/Deatsilence/go-stocket/helpers"
 	routes "github.com/Deatsilence/go-stocket/routes"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +33,23 @@ func main() {
 	routes.AuthRoutes(router)
 	routes.UserRoutes(router)
 	routes.ProductRoutes(router)
+	routes.ExchangeRateRoutes(router)
+	routes.CountRoutes(router)
+	routes.ReportRoutes(router)
+	routes.TransactionRoutes(router)
+	routes.SavedSearchRoutes(router)
+	routes.AttributeRoutes(router)
+	routes.WorkOrderRoutes(router)
+	routes.StatsRoutes(router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := helper.EnsureSearchIndex(ctx); err != nil {
+		log.Printf("Error while creating the product search index: %v", err)
+	}
+	cancel()
+
+	helper.StartPriceScheduler(time.Minute)
+	helper.StartSavedSearchDigests(time.Hour)
 
 	router.Run(":" + port)
 }
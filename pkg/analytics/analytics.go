@@ -0,0 +1,121 @@
+// Package analytics buckets stock movements over time for charts.
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is the width of a time bucket.
+type Interval string
+
+const (
+	Hour  Interval = "hour"
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+)
+
+// MaxBuckets caps the buckets of a series so that a wide range with a narrow interval
+// cannot produce an unbounded response.
+const MaxBuckets = 2000
+
+var (
+	ErrInvalidRange   = errors.New("the range ends before it starts")
+	ErrTooManyBuckets = fmt.Errorf("the range spans more than %d buckets, use a wider interval", MaxBuckets)
+)
+
+// ParseInterval reads an interval name, defaulting to a day when empty.
+func ParseInterval(name string) (Interval, error) {
+	switch Interval(strings.ToLower(name)) {
+	case "", Day:
+		return Day, nil
+	case Hour:
+		return Hour, nil
+	case Week:
+		return Week, nil
+	case Month:
+		return Month, nil
+	}
+	return "", fmt.Errorf("unknown interval %q, use hour, day, week or month", name)
+}
+
+// Truncate returns the start of the bucket holding t in UTC, the way $dateTrunc does
+// with weeks starting on Monday.
+func Truncate(t time.Time, interval Interval) time.Time {
+	t = t.UTC()
+	switch interval {
+	case Hour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)
+	case Week:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns the start of the bucket after the one starting at start.
+func Next(start time.Time, interval Interval) time.Time {
+	switch interval {
+	case Hour:
+		return start.Add(time.Hour)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Back returns the start of the bucket count intervals before the one holding t, so
+// that the range from it through t spans count+1 buckets.
+func Back(t time.Time, interval Interval, count int) time.Time {
+	start := Truncate(t, interval)
+	switch interval {
+	case Hour:
+		return start.Add(-time.Duration(count) * time.Hour)
+	case Week:
+		return start.AddDate(0, 0, -7*count)
+	case Month:
+		return start.AddDate(0, -count, 0)
+	}
+	return start.AddDate(0, 0, -count)
+}
+
+// Bucket holds the movements of one interval starting at Start.
+type Bucket struct {
+	Start        time.Time `json:"start"`
+	Inbound      uint      `json:"inbound"`
+	Outbound     uint      `json:"outbound"`
+	Transactions int64     `json:"transactions"`
+}
+
+// Fill returns a bucket for every interval from the one holding from through the one
+// holding to, taking the counts of the given buckets and leaving the others empty.
+// Buckets outside the range are dropped.
+func Fill(buckets []Bucket, from time.Time, to time.Time, interval Interval) ([]Bucket, error) {
+	if to.Before(from) {
+		return nil, ErrInvalidRange
+	}
+
+	byStart := map[time.Time]Bucket{}
+	for _, bucket := range buckets {
+		byStart[Truncate(bucket.Start, interval)] = bucket
+	}
+
+	filled := []Bucket{}
+	last := Truncate(to, interval)
+	for start := Truncate(from, interval); !start.After(last); start = Next(start, interval) {
+		if len(filled) == MaxBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		bucket := byStart[start]
+		bucket.Start = start
+		filled = append(filled, bucket)
+	}
+	return filled, nil
+}
@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestParseInterval(t *testing.T) {
+	interval, err := ParseInterval("")
+	assert.NoError(t, err)
+	assert.Equal(t, Day, interval)
+
+	interval, err = ParseInterval("Week")
+	assert.NoError(t, err)
+	assert.Equal(t, Week, interval)
+
+	_, err = ParseInterval("quarter")
+	assert.Error(t, err)
+}
+
+func TestTruncate(t *testing.T) {
+	// Thursday 2024-02-29 13:45 UTC.
+	moment := time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC)
+
+	assert.Equal(t, date(2024, 2, 29, 13), Truncate(moment, Hour))
+	assert.Equal(t, date(2024, 2, 29, 0), Truncate(moment, Day))
+	assert.Equal(t, date(2024, 2, 26, 0), Truncate(moment, Week))
+	assert.Equal(t, date(2024, 2, 1, 0), Truncate(moment, Month))
+
+	// A Sunday belongs to the week that started the Monday before.
+	assert.Equal(t, date(2024, 2, 26, 0), Truncate(date(2024, 3, 3, 23), Week))
+	// Times in other zones are bucketed in UTC.
+	assert.Equal(t, date(2024, 2, 29, 0), Truncate(time.Date(2024, 3, 1, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*3600)), Day))
+}
+
+func TestBack(t *testing.T) {
+	moment := time.Date(2024, 3, 31, 13, 45, 0, 0, time.UTC)
+
+	assert.Equal(t, date(2024, 3, 31, 10), Back(moment, Hour, 3))
+	assert.Equal(t, date(2024, 3, 1, 0), Back(moment, Day, 30))
+	assert.Equal(t, date(2024, 3, 11, 0), Back(moment, Week, 2))
+	assert.Equal(t, date(2023, 4, 1, 0), Back(moment, Month, 11))
+}
+
+func TestFill(t *testing.T) {
+	buckets := []Bucket{
+		{Start: date(2024, 3, 2, 0), Inbound: 5, Transactions: 1},
+		{Start: date(2024, 3, 4, 0), Outbound: 2, Transactions: 2},
+		{Start: date(2024, 3, 9, 0), Inbound: 1, Transactions: 1},
+	}
+
+	filled, err := Fill(buckets, date(2024, 3, 1, 8), date(2024, 3, 4, 20), Day)
+	assert.NoError(t, err)
+	assert.Equal(t, []Bucket{
+		{Start: date(2024, 3, 1, 0)},
+		{Start: date(2024, 3, 2, 0), Inbound: 5, Transactions: 1},
+		{Start: date(2024, 3, 3, 0)},
+		{Start: date(2024, 3, 4, 0), Outbound: 2, Transactions: 2},
+	}, filled)
+}
+
+func TestFillMonths(t *testing.T) {
+	filled, err := Fill(nil, date(2024, 1, 31, 0), date(2024, 4, 1, 0), Month)
+	assert.NoError(t, err)
+	assert.Len(t, filled, 4)
+	assert.Equal(t, date(2024, 2, 1, 0), filled[1].Start)
+	assert.Equal(t, date(2024, 4, 1, 0), filled[3].Start)
+}
+
+func TestFillLimits(t *testing.T) {
+	_, err := Fill(nil, date(2024, 3, 2, 0), date(2024, 3, 1, 0), Day)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	_, err = Fill(nil, date(2020, 1, 1, 0), date(2024, 1, 1, 0), Hour)
+	assert.ErrorIs(t, err, ErrTooManyBuckets)
+}
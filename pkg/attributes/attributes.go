@@ -0,0 +1,281 @@
+// Package attributes checks product attribute values against the schema of their
+// category and builds filters over them.
+package attributes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxTextLength is the longest text value, in characters.
+	MaxTextLength = 200
+	// MaxListItems is the largest number of items a list value may have.
+	MaxListItems = 50
+)
+
+// Field is the product field the attribute values are kept under.
+const Field = "attributes"
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ValidName reports whether a name can be used for an attribute. Names are lower case
+// letters, digits and underscores so they are safe to use as field paths.
+func ValidName(name string) bool {
+	return len(name) <= 30 && namePattern.MatchString(name)
+}
+
+// ValidateSchema checks that the definitions of a schema make sense together.
+func ValidateSchema(definitions []models.AttributeDefinition) error {
+	seen := map[string]bool{}
+	for _, definition := range definitions {
+		name := definition.Name
+		if !ValidName(name) {
+			return fmt.Errorf("attribute name %q must start with a lower case letter and have only lower case letters, digits and underscores", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("attribute %s is defined twice", name)
+		}
+		seen[name] = true
+
+		switch definition.Type {
+		case "enum":
+			if len(definition.Values) == 0 {
+				return fmt.Errorf("enum attribute %s needs its values", name)
+			}
+		case "list":
+		default:
+			if len(definition.Values) > 0 {
+				return fmt.Errorf("only enum and list attributes can have values, %s is a %s", name, definition.Type)
+			}
+		}
+		values := map[string]bool{}
+		for _, value := range definition.Values {
+			key := strings.ToLower(value)
+			if values[key] {
+				return fmt.Errorf("attribute %s has the value %q twice", name, value)
+			}
+			values[key] = true
+		}
+
+		if definition.Type != "number" && (definition.Unit != "" || definition.Min != nil || definition.Max != nil) {
+			return fmt.Errorf("only number attributes can have a unit, min or max, %s is a %s", name, definition.Type)
+		}
+		if definition.Min != nil && definition.Max != nil && *definition.Min > *definition.Max {
+			return fmt.Errorf("the min of attribute %s is above its max", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks attribute values against the definitions of their category and
+// returns them normalized: numbers as float64, lists without duplicates and enum values
+// spelled as in the schema. Null values are left out.
+func Validate(definitions []models.AttributeDefinition, values map[string]interface{}) (map[string]interface{}, error) {
+	byName := map[string]models.AttributeDefinition{}
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	normalized := map[string]interface{}{}
+	for _, name := range names {
+		if values[name] == nil {
+			continue
+		}
+		definition, ok := byName[name]
+		if !ok {
+			if len(definitions) == 0 {
+				return nil, fmt.Errorf("the category has no attributes, %s cannot be set", name)
+			}
+			return nil, fmt.Errorf("unknown attribute %s, use one of %s", name, strings.Join(definedNames(definitions), ", "))
+		}
+		value, err := validateValue(definition, values[name])
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		normalized[name] = value
+	}
+
+	for _, definition := range definitions {
+		if _, ok := normalized[definition.Name]; definition.Required && !ok {
+			return nil, fmt.Errorf("attribute %s is required", definition.Name)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+func definedNames(definitions []models.AttributeDefinition) []string {
+	names := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+	}
+	return names
+}
+
+func validateValue(definition models.AttributeDefinition, value interface{}) (interface{}, error) {
+	switch definition.Type {
+	case "text":
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be text")
+		}
+		if utf8.RuneCountInString(text) > MaxTextLength {
+			return nil, fmt.Errorf("must be at most %d characters", MaxTextLength)
+		}
+		return text, nil
+
+	case "number":
+		number, ok := toNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+		if definition.Min != nil && number < *definition.Min {
+			return nil, fmt.Errorf("must be at least %v%s", *definition.Min, definition.Unit)
+		}
+		if definition.Max != nil && number > *definition.Max {
+			return nil, fmt.Errorf("must be at most %v%s", *definition.Max, definition.Unit)
+		}
+		return number, nil
+
+	case "boolean":
+		boolean, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return boolean, nil
+
+	case "enum":
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(definition.Values, ", "))
+		}
+		return allowedValue(definition, text)
+
+	case "list":
+		var items []interface{}
+		switch list := value.(type) {
+		case []interface{}:
+			items = list
+		case primitive.A:
+			items = list
+		default:
+			return nil, fmt.Errorf("must be a list")
+		}
+		if len(items) > MaxListItems {
+			return nil, fmt.Errorf("can have at most %d items", MaxListItems)
+		}
+		seen := map[string]bool{}
+		normalized := []string{}
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of text")
+			}
+			if len(definition.Values) > 0 {
+				var err error
+				if text, err = allowedValue(definition, text); err != nil {
+					return nil, err
+				}
+			}
+			if utf8.RuneCountInString(text) > MaxTextLength {
+				return nil, fmt.Errorf("items must be at most %d characters", MaxTextLength)
+			}
+			if !seen[text] {
+				seen[text] = true
+				normalized = append(normalized, text)
+			}
+		}
+		return normalized, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", definition.Type)
+}
+
+func allowedValue(definition models.AttributeDefinition, text string) (string, error) {
+	for _, allowed := range definition.Values {
+		if strings.EqualFold(allowed, text) {
+			return allowed, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not one of %s", text, strings.Join(definition.Values, ", "))
+}
+
+// toNumber reads a number decoded from JSON or BSON.
+func toNumber(value interface{}) (float64, bool) {
+	var number float64
+	switch n := value.(type) {
+	case float64:
+		number = n
+	case int:
+		number = float64(n)
+	case int32:
+		number = float64(n)
+	case int64:
+		number = float64(n)
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, false
+		}
+		number = f
+	default:
+		return 0, false
+	}
+	return number, !math.IsNaN(number) && !math.IsInf(number, 0)
+}
+
+// Match builds the filter for products whose attribute equals a value taken from a query
+// string. The type of the attribute is not known there, so the value is matched as text
+// ignoring case and, when it reads as one, as a number or a boolean. A list attribute
+// matches when any of its items does.
+func Match(name string, value string) (bson.D, error) {
+	if !ValidName(name) {
+		return nil, fmt.Errorf("unknown attribute %q", name)
+	}
+	candidates := bson.A{primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}}
+	if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+		candidates = append(candidates, number)
+	}
+	if boolean, err := strconv.ParseBool(value); err == nil {
+		candidates = append(candidates, boolean)
+	}
+	return bson.D{{Key: Field + "." + name, Value: bson.D{{Key: "$in", Value: candidates}}}}, nil
+}
+
+// Range builds the filter for products whose number attribute lies between min and max,
+// both included. Either end may be empty.
+func Range(name string, min string, max string) (bson.D, error) {
+	if !ValidName(name) {
+		return nil, fmt.Errorf("unknown attribute %q", name)
+	}
+	bounds := bson.D{}
+	for _, bound := range []struct{ value, operator, label string }{{min, "$gte", "min"}, {max, "$lte", "max"}} {
+		if bound.value == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(bound.value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("the %s of attribute %s must be a number", bound.label, name)
+		}
+		bounds = append(bounds, bson.E{Key: bound.operator, Value: number})
+	}
+	return bson.D{{Key: Field + "." + name, Value: bounds}}, nil
+}
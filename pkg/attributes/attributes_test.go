@@ -0,0 +1,115 @@
+package attributes
+
+import (
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func limit(value float64) *float64 {
+	return &value
+}
+
+func electronics() []models.AttributeDefinition {
+	return []models.AttributeDefinition{
+		{Name: "voltage", Type: "number", Required: true, Unit: "V", Min: limit(0), Max: limit(1000)},
+		{Name: "warranty", Type: "number", Unit: "months"},
+		{Name: "plug", Type: "enum", Values: []string{"EU", "UK", "US"}},
+		{Name: "wireless", Type: "boolean"},
+		{Name: "allergens", Type: "list", Values: []string{"gluten", "nuts"}},
+		{Name: "colour", Type: "text"},
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	assert.NoError(t, ValidateSchema(electronics()))
+
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "Voltage", Type: "number"}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "a.b", Type: "text"}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "plug", Type: "enum"}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "colour", Type: "text", Values: []string{"red"}}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "colour", Type: "text", Unit: "V"}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "plug", Type: "enum", Values: []string{"EU", "eu"}}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "size", Type: "number", Min: limit(2), Max: limit(1)}}))
+	assert.Error(t, ValidateSchema([]models.AttributeDefinition{{Name: "size", Type: "number"}, {Name: "size", Type: "text"}}))
+}
+
+func TestValidate(t *testing.T) {
+	values, err := Validate(electronics(), map[string]interface{}{
+		"voltage":   230.0,
+		"plug":      "eu",
+		"wireless":  true,
+		"allergens": []interface{}{"Nuts", "nuts"},
+		"colour":    nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"voltage":   230.0,
+		"plug":      "EU",
+		"wireless":  true,
+		"allergens": []string{"nuts"},
+	}, values)
+
+	// Values read back from the database.
+	values, err = Validate(electronics(), map[string]interface{}{"voltage": int32(12), "allergens": primitive.A{"gluten"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 12.0, values["voltage"])
+	assert.Equal(t, []string{"gluten"}, values["allergens"])
+}
+
+func TestValidateErrors(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"attribute voltage is required":                   {},
+		"unknown attribute size":                          {"voltage": 1.0, "size": 2.0},
+		"attribute voltage: must be a number":             {"voltage": "230"},
+		"attribute voltage: must be at most 1000V":        {"voltage": 1001.0},
+		"attribute plug: \"JP\" is not one of EU, UK, US": {"voltage": 1.0, "plug": "JP"},
+		"attribute wireless: must be true or false":       {"voltage": 1.0, "wireless": "yes"},
+		"attribute allergens: must be a list":             {"voltage": 1.0, "allergens": "nuts"},
+		"attribute colour: must be text":                  {"voltage": 1.0, "colour": 3.0},
+	}
+	for message, values := range cases {
+		_, err := Validate(electronics(), values)
+		if assert.Error(t, err, message) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+
+	_, err := Validate(nil, map[string]interface{}{"voltage": 1.0})
+	assert.EqualError(t, err, "the category has no attributes, voltage cannot be set")
+
+	values, err := Validate(nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+}
+
+func TestMatch(t *testing.T) {
+	filter, err := Match("voltage", "230")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "attributes.voltage", Value: bson.D{{Key: "$in", Value: bson.A{
+		primitive.Regex{Pattern: "^230$", Options: "i"}, 230.0,
+	}}}}}, filter)
+
+	filter, err = Match("wireless", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.A{primitive.Regex{Pattern: "^true$", Options: "i"}, true}, filter[0].Value.(bson.D)[0].Value)
+
+	filter, err = Match("colour", "a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.A{primitive.Regex{Pattern: `^a\.b$`, Options: "i"}}, filter[0].Value.(bson.D)[0].Value)
+
+	_, err = Match("$where", "1")
+	assert.Error(t, err)
+}
+
+func TestRange(t *testing.T) {
+	filter, err := Range("voltage", "100", "")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "attributes.voltage", Value: bson.D{{Key: "$gte", Value: 100.0}}}}, filter)
+
+	_, err = Range("voltage", "high", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+// Package barcode recognises the barcode payloads stocket accepts and checks their
+// GS1 check digits.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Symbology string
+
+const (
+	EAN8    Symbology = "EAN-8"
+	EAN13   Symbology = "EAN-13"
+	UPCA    Symbology = "UPC-A"
+	GTIN14  Symbology = "GTIN-14"
+	Code128 Symbology = "Code128"
+)
+
+// MaxCode128Length is the longest Code128 payload accepted as a product barcode.
+const MaxCode128Length = 48
+
+var (
+	ErrEmpty            = errors.New("barcode is empty")
+	ErrInvalidCheck     = errors.New("barcode check digit is wrong")
+	ErrInvalidCharacter = errors.New("barcode contains characters Code128 cannot encode")
+	ErrTooLong          = fmt.Errorf("barcode is longer than %d characters", MaxCode128Length)
+)
+
+// Detect returns the symbology of a payload. Numeric payloads of 8, 12, 13 or 14 digits
+// are GS1 codes and must carry a valid check digit; everything else must be encodable
+// as Code128.
+func Detect(code string) (Symbology, error) {
+	if code == "" {
+		return "", ErrEmpty
+	}
+
+	if isDigits(code) {
+		var symbology Symbology
+		switch len(code) {
+		case 8:
+			symbology = EAN8
+		case 12:
+			symbology = UPCA
+		case 13:
+			symbology = EAN13
+		case 14:
+			symbology = GTIN14
+		}
+		if symbology != "" {
+			if !HasValidCheckDigit(code) {
+				return "", ErrInvalidCheck
+			}
+			return symbology, nil
+		}
+	}
+
+	if len(code) > MaxCode128Length {
+		return "", ErrTooLong
+	}
+	for _, r := range code {
+		if r < 32 || r > 126 {
+			return "", ErrInvalidCharacter
+		}
+	}
+	return Code128, nil
+}
+
+// Validate reports why a payload is not an acceptable barcode, or nil.
+func Validate(code string) error {
+	_, err := Detect(code)
+	return err
+}
+
+// CheckDigit computes the GS1 mod 10 check digit for the digits of a code without its
+// check digit.
+func CheckDigit(digits string) (int, error) {
+	if digits == "" || !isDigits(digits) {
+		return 0, errors.New("check digits are computed over digits only")
+	}
+
+	sum := 0
+	// Weights alternate 3, 1, ... starting from the rightmost digit.
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// HasValidCheckDigit reports whether the last digit of a GS1 code matches its check digit.
+func HasValidCheckDigit(code string) bool {
+	if len(code) < 2 || !isDigits(code) {
+		return false
+	}
+	check, err := CheckDigit(code[:len(code)-1])
+	if err != nil {
+		return false
+	}
+	return int(code[len(code)-1]-'0') == check
+}
+
+// Internal builds an EAN-13 code from a numeric prefix and a sequence number, for products
+// that arrive without a barcode of their own. GS1 reserves the prefixes 20 to 29 for such
+// in-store codes.
+func Internal(prefix string, sequence uint64) (string, error) {
+	if prefix == "" || !isDigits(prefix) || len(prefix) > 11 {
+		return "", fmt.Errorf("barcode prefix %q must be 1 to 11 digits", prefix)
+	}
+
+	width := 12 - len(prefix)
+	body := fmt.Sprintf("%0*d", width, sequence)
+	if len(body) > width {
+		return "", fmt.Errorf("sequence %d does not fit after prefix %q", sequence, prefix)
+	}
+
+	digits := prefix + body
+	check, _ := CheckDigit(digits)
+	return digits + fmt.Sprint(check), nil
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
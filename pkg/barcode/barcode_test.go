@@ -0,0 +1,51 @@
+package barcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]Symbology{
+		"96385074":       EAN8,
+		"4006381333931":  EAN13,
+		"036000291452":   UPCA,
+		"10614141000415": GTIN14,
+		"ABC-123":        Code128,
+		"12345":          Code128,
+	}
+	for code, expected := range cases {
+		symbology, err := Detect(code)
+		assert.NoError(t, err, code)
+		assert.Equal(t, expected, symbology, code)
+	}
+}
+
+func TestDetectRejectsBadPayloads(t *testing.T) {
+	_, err := Detect("4006381333932")
+	assert.ErrorIs(t, err, ErrInvalidCheck)
+
+	_, err = Detect("")
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	_, err = Detect("café")
+	assert.ErrorIs(t, err, ErrInvalidCharacter)
+}
+
+func TestInternal(t *testing.T) {
+	code, err := Internal("200", 42)
+	assert.NoError(t, err)
+	assert.Equal(t, "2000000000428", code)
+	assert.True(t, HasValidCheckDigit(code))
+
+	symbology, err := Detect(code)
+	assert.NoError(t, err)
+	assert.Equal(t, EAN13, symbology)
+
+	_, err = Internal("20A", 1)
+	assert.Error(t, err)
+
+	_, err = Internal("20000000000", 10)
+	assert.Error(t, err)
+}
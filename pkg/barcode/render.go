@@ -0,0 +1,210 @@
+package barcode
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	bc "github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// RenderOptions controls the size of a rendered code. All sizes are in pixels.
+type RenderOptions struct {
+	Scale  int  /// The width of one module
+	Height int  /// The height of the bars of a linear code, ignored for QR codes
+	Margin int  /// The quiet zone around the code
+	Text   bool /// Print the human-readable payload under a linear code
+}
+
+// DefaultRenderOptions returns sensible options for a linear barcode or a QR code.
+func DefaultRenderOptions(qrCode bool) RenderOptions {
+	if qrCode {
+		return RenderOptions{Scale: 4, Margin: 16}
+	}
+	return RenderOptions{Scale: 2, Height: 60, Margin: 10, Text: true}
+}
+
+// Symbol is an encoded code ready to be drawn, with its modules as a grid of dark cells.
+type Symbol struct {
+	Symbology Symbology
+	Content   string
+	modules   [][]bool
+	linear    bool
+}
+
+// Encode encodes a product barcode in its own symbology. EAN-8 and EAN-13 are drawn as
+// such, UPC-A as the EAN-13 it is equivalent to, and everything else, GTIN-14 included,
+// as Code128.
+func Encode(code string) (Symbol, error) {
+	symbology, err := Detect(code)
+	if err != nil {
+		return Symbol{}, err
+	}
+
+	var encoded bc.Barcode
+	switch symbology {
+	case EAN8, EAN13:
+		encoded, err = ean.Encode(code)
+	case UPCA:
+		encoded, err = ean.Encode("0" + code)
+	default:
+		encoded, err = code128.Encode(code)
+	}
+	if err != nil {
+		return Symbol{}, err
+	}
+	return newSymbol(encoded, symbology, code, true), nil
+}
+
+// EncodeQR encodes content, usually a link to a product, as a QR code.
+func EncodeQR(content string) (Symbol, error) {
+	encoded, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return Symbol{}, err
+	}
+	return newSymbol(encoded, "QR", content, false), nil
+}
+
+func newSymbol(encoded bc.Barcode, symbology Symbology, content string, linear bool) Symbol {
+	bounds := encoded.Bounds()
+	rows := bounds.Dy()
+	if linear {
+		rows = 1
+	}
+
+	modules := make([][]bool, rows)
+	for y := 0; y < rows; y++ {
+		modules[y] = make([]bool, bounds.Dx())
+		for x := 0; x < bounds.Dx(); x++ {
+			gray := color.GrayModel.Convert(encoded.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			modules[y][x] = gray.Y < 128
+		}
+	}
+	return Symbol{Symbology: symbology, Content: content, modules: modules, linear: linear}
+}
+
+const textHeight = 16
+
+type layout struct {
+	width, height, barHeight, scale, margin int
+	text                                    bool
+}
+
+func (s Symbol) layout(opts RenderOptions) layout {
+	l := layout{scale: opts.Scale, margin: opts.Margin, text: opts.Text && s.linear}
+	if l.scale < 1 {
+		l.scale = 1
+	}
+	if l.margin < 0 {
+		l.margin = 0
+	}
+
+	l.width = len(s.modules[0])*l.scale + 2*l.margin
+	if s.linear {
+		l.barHeight = opts.Height
+		if l.barHeight < 1 {
+			l.barHeight = DefaultRenderOptions(false).Height
+		}
+	} else {
+		l.barHeight = len(s.modules) * l.scale
+	}
+	l.height = l.barHeight + 2*l.margin
+	if l.text {
+		l.height += textHeight
+	}
+	return l
+}
+
+// Image draws the symbol on a white background.
+func (s Symbol) Image(opts RenderOptions) image.Image {
+	l := s.layout(opts)
+	img := image.NewGray(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	moduleHeight := l.scale
+	if s.linear {
+		moduleHeight = l.barHeight
+	}
+	for y, row := range s.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			rect := image.Rect(
+				l.margin+x*l.scale, l.margin+y*moduleHeight,
+				l.margin+(x+1)*l.scale, l.margin+(y+1)*moduleHeight,
+			)
+			draw.Draw(img, rect, image.Black, image.Point{}, draw.Src)
+		}
+	}
+
+	if l.text {
+		face := basicfont.Face7x13
+		drawer := font.Drawer{Dst: img, Src: image.Black, Face: face}
+		textWidth := drawer.MeasureString(s.Content).Round()
+		drawer.Dot = fixed.P((l.width-textWidth)/2, l.margin+l.barHeight+face.Ascent+2)
+		drawer.DrawString(s.Content)
+	}
+	return img
+}
+
+// WritePNG renders the symbol as a PNG image.
+func (s Symbol) WritePNG(w io.Writer, opts RenderOptions) error {
+	return png.Encode(w, s.Image(opts))
+}
+
+// WriteSVG renders the symbol as an SVG document.
+func (s Symbol) WriteSVG(w io.Writer, opts RenderOptions) error {
+	l := s.layout(opts)
+
+	if _, err := fmt.Fprintf(w,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><g fill="#000">`,
+		l.width, l.height, l.width, l.height); err != nil {
+		return err
+	}
+
+	moduleHeight := l.scale
+	if s.linear {
+		moduleHeight = l.barHeight
+	}
+	for y, row := range s.modules {
+		// Merge runs of dark modules into one rectangle to keep the document small.
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			if _, err := fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d"/>`,
+				l.margin+start*l.scale, l.margin+y*moduleHeight, (x-start)*l.scale, moduleHeight); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(w, "</g>"); err != nil {
+		return err
+	}
+
+	if l.text {
+		if _, err := fmt.Fprintf(w,
+			`<text x="%d" y="%d" font-family="monospace" font-size="13" text-anchor="middle">%s</text>`,
+			l.width/2, l.margin+l.barHeight+textHeight-3, html.EscapeString(s.Content)); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "</svg>")
+	return err
+}
@@ -0,0 +1,54 @@
+package barcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSymbologies(t *testing.T) {
+	ean13, err := Encode("4006381333931")
+	assert.NoError(t, err)
+	assert.Equal(t, EAN13, ean13.Symbology)
+	assert.Len(t, ean13.modules[0], 95)
+
+	upca, err := Encode("036000291452")
+	assert.NoError(t, err)
+	assert.Equal(t, UPCA, upca.Symbology)
+	assert.Len(t, upca.modules[0], 95)
+
+	code128, err := Encode("ABC-123")
+	assert.NoError(t, err)
+	assert.Equal(t, Code128, code128.Symbology)
+
+	_, err = Encode("4006381333932")
+	assert.Error(t, err)
+}
+
+func TestWritePNG(t *testing.T) {
+	symbol, _ := Encode("4006381333931")
+	opts := RenderOptions{Scale: 2, Height: 50, Margin: 10, Text: true}
+
+	var buf bytes.Buffer
+	assert.NoError(t, symbol.WritePNG(&buf, opts))
+
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 95*2+20, img.Bounds().Dx())
+	assert.Equal(t, 50+20+textHeight, img.Bounds().Dy())
+}
+
+func TestWriteSVG(t *testing.T) {
+	symbol, _ := EncodeQR("https://example.com/api/products/1")
+
+	var buf bytes.Buffer
+	assert.NoError(t, symbol.WriteSVG(&buf, DefaultRenderOptions(true)))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.True(t, strings.HasSuffix(svg, "</svg>"))
+	assert.NotContains(t, svg, "<text")
+}
@@ -0,0 +1,45 @@
+// Package bundle works out the stock of bundles from the stock of their components.
+package bundle
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+)
+
+// ErrQuantityTooLarge is returned when the components needed overflow a stock quantity.
+var ErrQuantityTooLarge = errors.New("the bundle quantity is too large")
+
+// Available is the number of whole bundles the stock of the components can make. A
+// component missing from stock counts as having none.
+func Available(components []models.BundleComponent, stock map[string]uint) uint {
+	if len(components) == 0 {
+		return 0
+	}
+	available := uint(math.MaxUint32)
+	for _, component := range components {
+		if component.Quantity == 0 {
+			continue
+		}
+		if made := stock[component.ProductID] / component.Quantity; made < available {
+			available = made
+		}
+	}
+	return available
+}
+
+// Requirements is the quantity of each component that quantity bundles need. A component
+// listed twice is needed for both lines.
+func Requirements(components []models.BundleComponent, quantity uint) (map[string]uint, error) {
+	needed := map[string]uint{}
+	for _, component := range components {
+		total := uint64(component.Quantity) * uint64(quantity)
+		total += uint64(needed[component.ProductID])
+		if total > math.MaxUint32 {
+			return nil, ErrQuantityTooLarge
+		}
+		needed[component.ProductID] = uint(total)
+	}
+	return needed, nil
+}
@@ -0,0 +1,31 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func giftBox() []models.BundleComponent {
+	return []models.BundleComponent{
+		{ProductID: "tea", Quantity: 2},
+		{ProductID: "mug", Quantity: 1},
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	assert.Equal(t, uint(3), Available(giftBox(), map[string]uint{"tea": 7, "mug": 10}))
+	assert.Equal(t, uint(2), Available(giftBox(), map[string]uint{"tea": 10, "mug": 2}))
+	assert.Equal(t, uint(0), Available(giftBox(), map[string]uint{"tea": 10}))
+	assert.Equal(t, uint(0), Available(nil, map[string]uint{"tea": 10}))
+}
+
+func TestRequirements(t *testing.T) {
+	needed, err := Requirements(append(giftBox(), models.BundleComponent{ProductID: "tea", Quantity: 1}), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint{"tea": 12, "mug": 4}, needed)
+
+	_, err = Requirements(giftBox(), 1<<31)
+	assert.ErrorIs(t, err, ErrQuantityTooLarge)
+}
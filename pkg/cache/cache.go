@@ -0,0 +1,64 @@
+// Package cache keeps values in memory for a short time.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTL is a cache whose entries expire a fixed time after they are stored. It is safe for
+// concurrent use.
+type TTL struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewTTL returns a cache whose entries live for ttl.
+func NewTTL(ttl time.Duration) *TTL {
+	return &TTL{ttl: ttl, now: time.Now, entries: map[string]entry{}}
+}
+
+// Get returns the value stored under key, if it has not expired.
+func (c *TTL) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(cached.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return cached.value, true
+}
+
+// Set stores a value under key and drops the entries that have expired.
+func (c *TTL) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, cached := range c.entries {
+		if !now.Before(cached.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry{value: value, expires: now.Add(c.ttl)}
+}
+
+// Clear drops every entry.
+func (c *TTL) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]entry{}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewTTL(30 * time.Second)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.Get("overview")
+	assert.False(t, ok)
+
+	c.Set("overview", 42)
+	value, ok := c.Get("overview")
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+
+	now = now.Add(29 * time.Second)
+	_, ok = c.Get("overview")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("overview")
+	assert.False(t, ok)
+	assert.Empty(t, c.entries)
+}
+
+func TestTTLSetDropsExpired(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewTTL(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	now = now.Add(2 * time.Minute)
+	c.Set("b", 2)
+	assert.Len(t, c.entries, 1)
+
+	c.Clear()
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+}
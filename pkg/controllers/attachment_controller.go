@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UploadAttachment adds the file in the multipart field "file" to a product.
+func UploadAttachment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, helper.MaxAttachmentSize+1<<20)
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A file of at most " + strconv.Itoa(helper.MaxAttachmentSize>>20) + " MB is required"})
+			return
+		}
+
+		attachment, err := helper.SaveAttachment(ctx, c.Param("productid"), c.GetString("userid"), fileHeader)
+		if err != nil {
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			case errors.Is(err, helper.ErrAttachmentTooLarge), errors.Is(err, helper.ErrAttachmentType),
+				errors.Is(err, helper.ErrTooManyAttachments), errors.Is(err, helper.ErrInvalidImage):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, helper.ErrProductArchived):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while saving file"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+// GetAttachment sends an attachment, or its thumbnail when thumbnail is true.
+func GetAttachment(thumbnail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		attachment, err := helper.FindAttachment(ctx, c.Param("productid"), c.Param("attachmentid"))
+		if err != nil || (thumbnail && attachment.ThumbnailKey == "") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+
+		key, contentType, disposition := attachment.Key, attachment.ContentType, "attachment"
+		if thumbnail {
+			key, contentType = attachment.ThumbnailKey, "image/png"
+			if attachment.ContentType == "image/jpeg" {
+				contentType = "image/jpeg"
+			}
+		}
+		if attachment.Kind == "image" || attachment.ContentType == "application/pdf" {
+			disposition = "inline"
+		}
+
+		file, err := helper.AttachmentStorage.Open(ctx, key)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		defer file.Close()
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", disposition+"; filename=\""+attachment.Name+"\"")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "private, max-age=86400")
+		c.Status(http.StatusOK)
+		io.Copy(c.Writer, file)
+	}
+}
+
+func DeleteAttachment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		err := helper.DeleteAttachment(ctx, c.Param("productid"), c.Param("attachmentid"))
+		if errors.Is(err, helper.ErrAttachmentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		if errors.Is(err, helper.ErrProductArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting attachment"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+	}
+}
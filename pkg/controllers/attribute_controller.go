@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/attributes"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var attributeSchemaCollection *mongo.Collection = database.OpenCollection(database.Client, "attributeschema")
+
+func GetAttributeSchemas() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		cursor, err := attributeSchemaCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"category": 1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding attribute schemas"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		schemas := []models.AttributeSchema{}
+		if err = cursor.All(ctx, &schemas); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding attribute schemas"})
+			return
+		}
+
+		c.JSON(http.StatusOK, schemas)
+	}
+}
+
+func GetAttributeSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		category, err := types.ParseCategory(c.Param("category"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		schema, err := helper.FindAttributeSchema(ctx, int(category))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding attribute schema"})
+			return
+		}
+		if schema.Attributes == nil {
+			schema.Attributes = []models.AttributeDefinition{}
+		}
+
+		c.JSON(http.StatusOK, schema)
+	}
+}
+
+// SetAttributeSchema replaces the attribute schema of a category. Products already saved
+// are not changed; they are checked against the new schema when they are next updated.
+func SetAttributeSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		category, err := types.ParseCategory(c.Param("category"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var schema models.AttributeSchema
+
+		if err := c.BindJSON(&schema); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(schema)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := attributes.ValidateSchema(schema.Attributes); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if schema.Attributes == nil {
+			schema.Attributes = []models.AttributeDefinition{}
+		}
+		schema.Category = int(category)
+		schema.UpdatedBy = c.GetString("userid")
+		schema.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		var saved models.AttributeSchema
+		err = attributeSchemaCollection.FindOneAndUpdate(ctx,
+			bson.M{"category": schema.Category},
+			bson.M{
+				"$set": bson.M{
+					"attributes": schema.Attributes,
+					"updatedby":  schema.UpdatedBy,
+					"updatedat":  schema.UpdatedAt,
+				},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&saved)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while saving attribute schema"})
+			return
+		}
+
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+// DeleteAttributeSchema removes the attribute schema of a category. The attributes
+// already saved on its products stay until the products are next updated.
+func DeleteAttributeSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		category, err := types.ParseCategory(c.Param("category"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := attributeSchemaCollection.DeleteOne(ctx, bson.M{"category": int(category)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting attribute schema"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attribute schema not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Attribute schema deleted successfully"})
+	}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/barcode"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetProductBarcode renders the barcode of a product as a png or svg image. The query
+// parameters scale, height, margin and text override the default size and text line.
+func GetProductBarcode(format string) gin.HandlerFunc {
+	return renderProductCode(format, false)
+}
+
+// GetProductQRCode renders a QR code that links to the product.
+func GetProductQRCode(format string) gin.HandlerFunc {
+	return renderProductCode(format, true)
+}
+
+func renderProductCode(format string, qrCode bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		productID := c.Param("productid")
+
+		var product models.Product
+
+		err := productCollection.FindOne(ctx, bson.M{"productid": productID}).Decode(&product)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+
+		var symbol barcode.Symbol
+		if qrCode {
+			link, linkErr := helper.ProductLink(product.ProductID)
+			if linkErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": linkErr.Error()})
+				return
+			}
+			symbol, err = barcode.EncodeQR(link)
+		} else {
+			symbol, err = barcode.Encode(product.Barcode)
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		opts, err := renderOptions(c, qrCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if format == "svg" {
+			c.Header("Content-Type", "image/svg+xml")
+			c.Status(http.StatusOK)
+			symbol.WriteSVG(c.Writer, opts)
+			return
+		}
+		c.Header("Content-Type", "image/png")
+		c.Status(http.StatusOK)
+		symbol.WritePNG(c.Writer, opts)
+	}
+}
+
+func renderOptions(c *gin.Context, qrCode bool) (barcode.RenderOptions, error) {
+	opts := barcode.DefaultRenderOptions(qrCode)
+
+	limits := []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"scale", &opts.Scale, 20},
+		{"height", &opts.Height, 1000},
+		{"margin", &opts.Margin, 200},
+	}
+	for _, limit := range limits {
+		raw := c.Query(limit.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > limit.max {
+			return opts, fmt.Errorf("%s must be a number between 0 and %d", limit.name, limit.max)
+		}
+		*limit.value = value
+	}
+
+	if raw := c.Query("text"); raw != "" {
+		text, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, errors.New("text must be true or false")
+		}
+		opts.Text = text
+	}
+	return opts, nil
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchUpdateProducts takes either a list of patches or a filter with an operation, for
+// example {"filter": {"category": "Drinks"}, "operation": {"field": "price", "op": "percent", "value": 5}}.
+func BatchUpdateProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var request models.BatchUpdate
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(request)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		byPatches := len(request.Patches) > 0 && request.Filter == nil && request.Operation == nil
+		byOperation := len(request.Patches) == 0 && request.Filter != nil && request.Operation != nil
+		if !byPatches && !byOperation {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Send either patches or a filter with an operation"})
+			return
+		}
+
+		summary, err := helper.BatchUpdateProducts(ctx, c.GetString("userid"), request)
+		if err != nil {
+			if len(summary.Results) > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "summary": summary})
+				return
+			}
+			if errors.Is(err, helper.ErrBatchTooLarge) || errors.Is(err, helper.ErrEmptyBatchFilter) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while updating products"})
+			return
+		}
+
+		c.JSON(http.StatusOK, summary)
+	}
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var countSessionCollection *mongo.Collection = database.OpenCollection(database.Client, "countsession")
+var validateCount = validator.New()
+
+func OpenCountSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var session models.CountSession
+
+		if err := c.BindJSON(&session); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateCount.Struct(session)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		lines, err := helper.CountLines(ctx, session.Categories, session.Locations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while freezing stock"})
+			return
+		}
+		if len(lines) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No products match the scope of the session"})
+			return
+		}
+
+		session.ID = primitive.NewObjectID()
+		session.SessionID = session.ID.Hex()
+		session.Status = string(types.CountOpen)
+		session.Lines = lines
+		session.OpenedBy = c.GetString("userid")
+		session.OpenedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		session.PostedBy = ""
+		session.PostedAt = time.Time{}
+
+		_, insertErr := countSessionCollection.InsertOne(ctx, session)
+		if insertErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while opening count session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+func GetCountSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+
+		opts := options.Find().
+			SetSort(bson.M{"openedat": -1}).
+			SetProjection(bson.M{"lines": 0})
+
+		cursor, err := countSessionCollection.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding count sessions"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		sessions := []models.CountSession{}
+		if err = cursor.All(ctx, &sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding count sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+func GetCountSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var session models.CountSession
+
+		err := countSessionCollection.FindOne(ctx, bson.M{"sessionid": c.Param("sessionid")}).Decode(&session)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Count session not found"})
+			return
+		}
+		helper.FillVariances(&session)
+
+		counted, withVariance := 0, 0
+		for _, line := range session.Lines {
+			if line.Counted != nil {
+				counted++
+			}
+			if line.Variance != nil && *line.Variance != 0 {
+				withVariance++
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"session":   session,
+			"total":     len(session.Lines),
+			"counted":   counted,
+			"variances": withVariance,
+		})
+	}
+}
+
+func SubmitCounts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		sessionID := c.Param("sessionid")
+
+		var requestBody struct {
+			Entries []models.CountEntry `json:"entries" validate:"required,min=1,dive"`
+		}
+		if err := c.BindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateCount.Struct(requestBody)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		userID := c.GetString("userid")
+		results := make([]gin.H, 0, len(requestBody.Entries))
+		accepted := 0
+
+		for i, entry := range requestBody.Entries {
+			productID, quantity, err := helper.ResolveCountEntry(ctx, entry)
+			if err == nil {
+				err = helper.SubmitCount(ctx, sessionID, productID, quantity, entry.Mode, userID)
+			}
+			if err != nil {
+				results = append(results, gin.H{"index": i, "error": err.Error()})
+				continue
+			}
+			accepted++
+			results = append(results, gin.H{"index": i, "productid": productID, "quantity": quantity})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"accepted": accepted,
+			"rejected": len(requestBody.Entries) - accepted,
+			"results":  results,
+		})
+	}
+}
+
+func PostCountSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		session, err := helper.PostCountSession(ctx, c.Param("sessionid"), c.GetString("userid"))
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Count session not found"})
+			return
+		}
+		if errors.Is(err, helper.ErrCountSessionNotOpen) || errors.Is(err, helper.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while posting count session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// CancelCountSession cancels an open count session. Admins can cancel any session and
+// other users only the sessions they opened.
+func CancelCountSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"sessionid": c.Param("sessionid"), "status": string(types.CountOpen)}
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			filter["openedby"] = c.GetString("userid")
+		}
+
+		result, err := countSessionCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": string(types.CountCancelled)}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while cancelling count session"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Open count session not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Count session cancelled successfully"})
+	}
+}
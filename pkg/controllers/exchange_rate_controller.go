@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var exchangeRateCollection *mongo.Collection = database.OpenCollection(database.Client, "exchangerate")
+var validateExchangeRate = validator.New()
+
+func GetExchangeRates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		cursor, err := exchangeRateCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"currency": 1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding exchange rates"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		rates := []models.ExchangeRate{}
+		if err = cursor.All(ctx, &rates); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding exchange rates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"basecurrency": helper.BASE_CURRENCY,
+			"rates":        rates,
+		})
+	}
+}
+
+func SetExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var requestBody struct {
+			Rate json.Number `json:"rate"`
+		}
+		if err := c.BindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rate, err := types.NewMoney(requestBody.Rate.String(), "")
+		if err != nil || rate.Rat().Sign() <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rate must be a positive number"})
+			return
+		}
+
+		exchangeRate := models.ExchangeRate{
+			Currency:  strings.ToUpper(c.Param("currency")),
+			Rate:      rate.Amount,
+			UpdatedBy: c.GetString("userid"),
+		}
+		exchangeRate.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		validationErr := validateExchangeRate.Struct(exchangeRate)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if exchangeRate.Currency == helper.BASE_CURRENCY {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The base currency has no exchange rate"})
+			return
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"rate":      exchangeRate.Rate,
+				"updatedby": exchangeRate.UpdatedBy,
+				"updatedat": exchangeRate.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+		}
+
+		_, err = exchangeRateCollection.UpdateOne(ctx, bson.M{"currency": exchangeRate.Currency}, update, options.Update().SetUpsert(true))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while saving exchange rate"})
+			return
+		}
+
+		c.JSON(http.StatusOK, exchangeRate)
+	}
+}
+
+func DeleteExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		currency := strings.ToUpper(c.Param("currency"))
+
+		result, err := exchangeRateCollection.DeleteOne(ctx, bson.M{"currency": currency})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting exchange rate"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+	}
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/export"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportFlushEvery is the number of records written between flushes to the client.
+const exportFlushEvery = 500
+
+func ExportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := helper.ProductFilter(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sort, err := helper.ProductSort(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: filter}}}
+		pipeline = append(pipeline, helper.ProductSortStages(sort)...)
+
+		streamExport(c, productCollection, pipeline, "products", helper.ProductColumns,
+			func(cursor *mongo.Cursor) (interface{}, []string, error) {
+				var product models.Product
+				if err := cursor.Decode(&product); err != nil {
+					return nil, nil, err
+				}
+				return product, helper.ProductRow(product), nil
+			})
+	}
+}
+
+func ExportTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := helper.TransactionFilter(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pipeline := mongo.Pipeline{
+			bson.D{{Key: "$match", Value: filter}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "processtime", Value: 1}, {Key: "_id", Value: 1}}}},
+		}
+
+		streamExport(c, transactionCollection, pipeline, "transactions", helper.TransactionColumns,
+			func(cursor *mongo.Cursor) (interface{}, []string, error) {
+				var transaction models.Transaction
+				if err := cursor.Decode(&transaction); err != nil {
+					return nil, nil, err
+				}
+				return transaction, helper.TransactionRow(transaction), nil
+			})
+	}
+}
+
+// streamExport writes every document the pipeline returns straight from the cursor, so
+// the result set is never held in memory. Once the first byte is sent the status can no
+// longer change, so a failure halfway through ends the response early and is logged.
+func streamExport(c *gin.Context, collection *mongo.Collection, pipeline mongo.Pipeline, name string, columns []string,
+	decode func(cursor *mongo.Cursor) (interface{}, []string, error)) {
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetBatchSize(exportFlushEvery))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while exporting " + name})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", "attachment; filename="+name+"."+string(format))
+	c.Status(http.StatusOK)
+
+	writer := export.NewWriter(c.Writer, format, columns)
+	for cursor.Next(ctx) {
+		record, row, err := decode(cursor)
+		if err != nil {
+			log.Printf("Error while exporting %v: %v", name, err)
+			return
+		}
+		if err := writer.Write(record, row); err != nil {
+			log.Printf("Error while exporting %v: %v", name, err)
+			return
+		}
+		if writer.Written()%exportFlushEvery == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Error while exporting %v: %v", name, err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("Error while exporting %v: %v", name, err)
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/forecast"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetProductForecast forecasts the demand of a product and suggests a reorder.
+func GetProductForecast() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		opts, err := forecast.OptionsFrom(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		predicted, err := helper.ForecastProduct(ctx, c.Param("productid"), opts)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if errors.Is(err, helper.ErrForecastBundle) || errors.Is(err, forecast.ErrNotEnoughHistory) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while forecasting demand"})
+			return
+		}
+
+		c.JSON(http.StatusOK, predicted)
+	}
+}
+
+// GetStockOutReport lists the active products expected to run out of stock within the
+// forecast horizon, optionally in one category.
+func GetStockOutReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		opts, err := forecast.OptionsFrom(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		productFilter := bson.M{}
+		if value := c.Query("category"); value != "" {
+			category, err := types.ParseCategory(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			productFilter["category"] = int(category)
+		}
+
+		report, err := helper.StockOutReport(ctx, productFilter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while forecasting stock outs"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"horizon": opts.Horizon, "products": report})
+	}
+}
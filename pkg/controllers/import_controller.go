@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportProducts reads a CSV or XLSX upload from the multipart field "file". The form
+// field "mapping" maps product fields to columns as "field=Column,field=Column" and the
+// query parameter dryrun=true only validates the rows.
+func ImportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryrun", c.PostForm("dryrun")))
+
+		mapping, err := importer.ParseMapping(c.PostForm("mapping"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		table, err := importer.Read(file, importer.DetectFormat(fileHeader.Filename))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rows, err := table.Products(mapping)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		summary, err := helper.ImportProducts(ctx, c.GetString("userid"), rows, dryRun)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while importing products", "summary": summary})
+			return
+		}
+
+		c.JSON(http.StatusOK, summary)
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/labels"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetLabelLayouts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		layouts := make([]labels.Layout, 0, len(labels.Layouts))
+		for _, name := range labels.LayoutNames() {
+			layouts = append(layouts, labels.Layouts[name])
+		}
+		c.JSON(http.StatusOK, layouts)
+	}
+}
+
+func PrintLabels() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request models.LabelRequest
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(request)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		if request.Layout == "" {
+			request.Layout = labels.DefaultLayout
+		}
+		layout, ok := labels.Layouts[request.Layout]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown layout, use one of " + strings.Join(labels.LayoutNames(), ", ")})
+			return
+		}
+		if request.Skip >= layout.PerPage() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Skip must be below " + strconv.Itoa(layout.PerPage()) + ", the number of labels on a sheet of " + request.Layout})
+			return
+		}
+
+		items, err := helper.LabelItems(ctx, request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(items) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No products found"})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := labels.Render(&buf, layout, items, request.Skip); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Disposition", "attachment; filename=labels.pdf")
+		c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+	}
+}
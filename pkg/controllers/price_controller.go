@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var priceChangeCollection *mongo.Collection = database.OpenCollection(database.Client, "pricechange")
+
+func GetPriceHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		productID := c.Param("productid")
+
+		var product models.Product
+
+		err := productCollection.FindOne(ctx, bson.M{"productid": productID}).Decode(&product)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+
+		cursor, err := priceChangeCollection.Find(ctx, bson.M{"productid": productID}, options.Find().SetSort(bson.D{
+			{Key: "effectiveat", Value: 1},
+			{Key: "createdat", Value: 1},
+		}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding price history"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		timeline := []models.PriceChange{}
+		if err = cursor.All(ctx, &timeline); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding price history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"productid":    productID,
+			"currentPrice": product.Price,
+			"timeline":     timeline,
+		})
+	}
+}
+
+func SchedulePriceChange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		productID := c.Param("productid")
+
+		var requestBody struct {
+			Price       *types.Money `json:"price" validate:"required"`
+			EffectiveAt time.Time    `json:"effectiveat" validate:"required"`
+		}
+		if err := c.BindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(requestBody)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := helper.NormalizePrice(requestBody.Price); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !requestBody.EffectiveAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Effective date must be in the future"})
+			return
+		}
+
+		count, err := productCollection.CountDocuments(ctx, bson.M{"productid": productID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err := helper.CheckProductsWritable(ctx, productID); err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		priceChange := models.PriceChange{
+			ID:          primitive.NewObjectID(),
+			ProductID:   productID,
+			NewPrice:    requestBody.Price,
+			Status:      string(types.PriceScheduled),
+			ChangedBy:   c.GetString("userid"),
+			EffectiveAt: requestBody.EffectiveAt.UTC(),
+		}
+		priceChange.PriceChangeID = priceChange.ID.Hex()
+		priceChange.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		_, insertErr := priceChangeCollection.InsertOne(ctx, priceChange)
+		if insertErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while scheduling price"})
+			return
+		}
+
+		c.JSON(http.StatusOK, priceChange)
+	}
+}
+
+func CancelPriceChange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{
+			"productid":     c.Param("productid"),
+			"pricechangeid": c.Param("pricechangeid"),
+			"status":        string(types.PriceScheduled),
+		}
+
+		result, err := priceChangeCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": string(types.PriceCancelled)}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while cancelling price"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled price not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Scheduled price cancelled successfully"})
+	}
+}
@@ -2,9 +2,12 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -14,14 +17,15 @@ import (
 	"github.com/Deatsilence/go-stocket/types"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/gin-gonic/gin/binding"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var productCollection *mongo.Collection = database.OpenCollection(database.Client, "product")
-var validateProduct = validator.New()
+var validateProduct = helper.NewValidator()
 
 func AddAProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -41,18 +45,74 @@ func AddAProduct() gin.HandlerFunc {
 			return
 		}
 
-		count, err := productCollection.CountDocuments(ctx, bson.M{"barcode": product.Barcode})
+		if product.BaseUnit == "" {
+			product.BaseUnit = helper.DefaultBaseUnit
+		}
+		if err := helper.ValidateUnits(product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.NormalizePrices(&product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.ValidateProductAttributes(ctx, &product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.ValidateBundle(ctx, "", &product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if product.Barcode == "" {
+			generated, err := helper.GenerateInternalBarcode(ctx)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while generating barcode"})
+				return
+			}
+			product.Barcode = generated
+		}
+
+		inUse, err := helper.BarcodeInUse(ctx, product.Barcode, "")
 
 		if err != nil {
 			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
 			return
 		}
-		if count > 0 {
+		if inUse {
 			c.JSON(http.StatusConflict, gin.H{"error": "Product already exists"})
 			return
 		}
 
+		for _, unit := range product.Units {
+			if unit.Barcode == "" {
+				continue
+			}
+			inUse, err := helper.BarcodeInUse(ctx, unit.Barcode, "")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
+				return
+			}
+			if inUse {
+				c.JSON(http.StatusConflict, gin.H{"error": "Barcode " + unit.Barcode + " is already in use"})
+				return
+			}
+		}
+
+		// New products start as drafts or active; later changes go through the status endpoint.
+		switch product.Status {
+		case "":
+			product.Status = string(types.ProductActive)
+		case string(types.ProductDraft), string(types.ProductActive):
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "New products can only be draft or active"})
+			return
+		}
+		// Attachments are only added by uploading them, and links through their own endpoint.
+		product.Attachments = nil
+		product.Links = nil
 		product.ID = primitive.NewObjectID()
 		product.ProductID = product.ID.Hex()
 		product.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -78,9 +138,24 @@ func DeleteAProduct() gin.HandlerFunc {
 
 		productID := c.Param("productid")
 
+		if err := helper.CheckProductsWritable(ctx, productID); err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		used, err := helper.BundlesUsing(ctx, productID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting product"})
+			return
+		}
+		if used > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Product is a component of a bundle"})
+			return
+		}
+
 		var product models.Product
 
-		err := productCollection.FindOneAndDelete(ctx, bson.M{"productid": productID}).Decode(&product)
+		err = productCollection.FindOneAndDelete(ctx, bson.M{"productid": productID, "status": bson.M{"$ne": string(types.ProductArchived)}}).Decode(&product)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting product"})
@@ -88,6 +163,10 @@ func DeleteAProduct() gin.HandlerFunc {
 		}
 		userID := c.GetString("userid")
 		helper.CreateTransactionForProduct(userID, product.ProductID, types.Delete, product.Stock)
+		helper.DeleteProductFiles(ctx, product)
+		if err := helper.RemoveLinksTo(ctx, product.ProductID); err != nil {
+			log.Printf("Error while removing links to product %v: %v", product.ProductID, err)
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 	}
@@ -108,18 +187,23 @@ func GetProducts() gin.HandlerFunc {
 			page = 1
 		}
 
-		prefix := c.Query("prefix")
-
 		startIndex := (page - 1) * recordPerPage
 
 		// Build the match stage of the pipeline with optional filters
-		matchStage := bson.D{}
-		if prefix != "" {
-			matchStage = append(matchStage, bson.E{Key: "barcode", Value: bson.D{{Key: "$regex", Value: "^" + prefix}, {Key: "$options", Value: "i"}}})
+		matchStage, err := helper.ProductFilter(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sortStage, err := helper.ProductSort(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 
-		pipeline := mongo.Pipeline{
-			bson.D{{Key: "$match", Value: matchStage}},
+		pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: matchStage}}}
+		pipeline = append(pipeline, helper.ProductSortStages(sortStage)...)
+		pipeline = append(pipeline,
 			bson.D{{Key: "$group", Value: bson.D{
 				{Key: "_id", Value: "null"},
 				{Key: "totalCount", Value: bson.D{{Key: "$sum", Value: 1}}},
@@ -136,17 +220,43 @@ func GetProducts() gin.HandlerFunc {
 							{Key: "productid", Value: "$$item.productid"},
 							{Key: "name", Value: "$$item.name"},
 							{Key: "price", Value: "$$item.price"},
+							{Key: "prices", Value: "$$item.prices"},
 							{Key: "barcode", Value: "$$item.barcode"},
+							{Key: "status", Value: "$$item.status"},
+							{Key: "kind", Value: "$$item.kind"},
+							{Key: "components", Value: "$$item.components"},
 							{Key: "stock", Value: "$$item.stock"},
+							{Key: "baseunit", Value: "$$item.baseunit"},
+							{Key: "units", Value: "$$item.units"},
+							{Key: "location", Value: "$$item.location"},
+							{Key: "attributes", Value: "$$item.attributes"},
+							{Key: "attachments", Value: "$$item.attachments"},
+							{Key: "links", Value: "$$item.links"},
 							{Key: "description", Value: "$$item.description"},
 							{Key: "createdat", Value: "$$item.createdat"},
 							{Key: "updatedat", Value: "$$item.updatedat"},
 							{Key: "category", Value: "$$item.category"}}},
 					}},
 				}}},
-			}}}
+			}},
+		)
+
+		var facets *helper.ProductFacets
+		if withFacets, _ := strconv.ParseBool(c.Query("facets")); withFacets {
+			priceBuckets, lowStock, err := helper.FacetOptions(c.Request.URL.Query())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			counted, err := helper.FacetProducts(ctx, matchStage, priceBuckets, lowStock)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while counting facets"})
+				return
+			}
+			facets = &counted
+		}
 
-		result, err := productCollection.Aggregate(ctx, pipeline)
+		result, err := productCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while paginating products"})
@@ -159,24 +269,39 @@ func GetProducts() gin.HandlerFunc {
 		}
 
 		if len(allProducts) > 0 {
+			if items, ok := allProducts[0]["productItems"].(primitive.A); ok {
+				if err := helper.FillBundleStock(ctx, items); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while computing bundle stock"})
+					return
+				}
+			}
+
 			// Calculate total pages
 			totalCount := allProducts[0]["totalCount"].(int32)
 			totalPages := (totalCount + int32(recordPerPage) - 1) / int32(recordPerPage)
 
 			// Return the response with pagination info
-			c.JSON(http.StatusOK, gin.H{
+			response := gin.H{
 				"productItems": allProducts[0]["productItems"],
 				"totalCount":   totalCount,
 				"totalPages":   totalPages,
 				"currentPage":  page,
-			})
+			}
+			if facets != nil {
+				response["facets"] = facets
+			}
+			c.JSON(http.StatusOK, response)
 		} else {
-			c.JSON(http.StatusOK, gin.H{
+			response := gin.H{
 				"productItems": []interface{}{},
 				"totalCount":   0,
 				"totalPages":   0,
 				"currentPage":  page,
-			})
+			}
+			if facets != nil {
+				response["facets"] = facets
+			}
+			c.JSON(http.StatusOK, response)
 		}
 	}
 }
@@ -196,6 +321,28 @@ func GetProduct() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Product not found"})
 			return
 		}
+		if product.Kind == string(types.Bundle) {
+			available, err := helper.BundleStock(ctx, []models.Product{product})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while computing bundle stock"})
+				return
+			}
+			product.Stock = available[product.ProductID]
+		}
+		if c.Query("expand") == "links" {
+			product.Linked, err = helper.LinkedProducts(ctx, product)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while looking up linked products"})
+				return
+			}
+		}
+		if product.Stock == 0 {
+			product.Substitutes, err = helper.InStockSubstitutes(ctx, product, 1)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while looking up substitutes"})
+				return
+			}
+		}
 		c.JSON(http.StatusOK, product)
 	}
 }
@@ -218,29 +365,95 @@ func UpdateAProduct() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
 			return
 		}
+		if err := helper.CheckProductsWritable(ctx, productID); err != nil {
+			writeProductError(c, err)
+			return
+		}
+		if err := helper.CheckStockIncrease(ctx, productID, product.Stock); err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		if product.BaseUnit == "" {
+			product.BaseUnit = helper.DefaultBaseUnit
+		}
+		if err := helper.ValidateUnits(product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.NormalizePrices(&product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.ValidateProductAttributes(ctx, &product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.ValidateBundle(ctx, productID, &product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, unit := range product.Units {
+			if unit.Barcode == "" {
+				continue
+			}
+			inUse, err := helper.BarcodeInUse(ctx, unit.Barcode, productID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
+				return
+			}
+			if inUse {
+				c.JSON(http.StatusConflict, gin.H{"error": "Barcode " + unit.Barcode + " is already in use"})
+				return
+			}
+		}
 
 		product.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 		update := bson.M{
 			"$set": bson.M{
 				"name":        product.Name,
-				"barcode":     product.Barcode,
 				"description": product.Description,
 				"category":    product.Category,
+				"kind":        product.Kind,
+				"components":  product.Components,
 				"stock":       product.Stock,
 				"price":       product.Price,
+				"prices":      product.Prices,
+				"baseunit":    product.BaseUnit,
+				"units":       product.Units,
+				"location":    product.Location,
+				"attributes":  product.Attributes,
 				"updatedat":   product.UpdatedAt,
 			},
 		}
 
-		_, err := productCollection.UpdateOne(ctx, bson.M{"productid": productID}, update)
+		// An update without a barcode keeps the current one.
+		if product.Barcode != "" {
+			inUse, err := helper.BarcodeInUse(ctx, product.Barcode, productID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
+				return
+			}
+			if inUse {
+				c.JSON(http.StatusConflict, gin.H{"error": "Barcode " + product.Barcode + " is already in use"})
+				return
+			}
+			update["$set"].(bson.M)["barcode"] = product.Barcode
+		}
+
+		var oldProduct models.Product
+
+		err := productCollection.FindOneAndUpdate(ctx, bson.M{"productid": productID}, update).Decode(&oldProduct)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while updating product"})
 			return
 		}
 		userID := c.GetString("userid")
-		helper.CreateTransactionForProduct(userID, product.ProductID, types.Update, product.Stock)
+		helper.CreateStockUpdateTransaction(userID, productID, oldProduct.Stock, product.Stock)
+		helper.RecordPriceChange(userID, productID, oldProduct.Price, product.Price)
 
 		c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
 	}
@@ -255,22 +468,82 @@ func UpdateSomePropertiesOfProduct() gin.HandlerFunc {
 
 		var product models.Product
 
-		if err := c.BindJSON(&product); err != nil {
+		if err := c.ShouldBindBodyWith(&product, binding.JSON); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// The stock is a plain number, so whether it was sent is read from the raw fields.
+		var fields map[string]json.RawMessage
+		if err := c.ShouldBindBodyWith(&fields, binding.JSON); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		_, hasStock := fields["stock"]
 
-		update := helper.UpdateFilter(product)
+		if err := validateProduct.Var(product.Barcode, "omitempty,barcode"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid barcode"})
+			return
+		}
+		if err := validateProduct.Var(product.Units, "omitempty,dive"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.CheckProductsWritable(ctx, productID); err != nil {
+			writeProductError(c, err)
+			return
+		}
+		if hasStock {
+			if err := helper.CheckStockIncrease(ctx, productID, product.Stock); err != nil {
+				writeProductError(c, err)
+				return
+			}
+		}
+		if err := helper.NormalizePrices(&product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helper.ValidatePartialUnits(ctx, productID, product); err != nil {
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			case errors.Is(err, helper.ErrInvalidUnits):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, helper.ErrBarcodeInUse):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while checking for product"})
+			}
+			return
+		}
+		if product.Kind != "" || product.Components != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The kind and components of a product can only be changed with a full update"})
+			return
+		}
+		if product.Attributes != nil || product.Category != nil {
+			if err := helper.ValidatePartialAttributes(ctx, productID, &product); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		update := helper.UpdateFilter(product, hasStock)
 
 		updated := bson.M{"$set": update}
 
-		_, err := productCollection.UpdateOne(ctx, bson.M{"productid": productID}, updated)
+		var oldProduct models.Product
+
+		err := productCollection.FindOneAndUpdate(ctx, bson.M{"productid": productID}, updated).Decode(&oldProduct)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while updating product"})
 			return
 		}
 		userID := c.GetString("userid")
-		helper.CreateTransactionForProduct(userID, productID, types.Update, product.Stock)
+		newStock := oldProduct.Stock
+		if hasStock {
+			newStock = product.Stock
+		}
+		helper.CreateStockUpdateTransaction(userID, productID, oldProduct.Stock, newStock)
+		helper.RecordPriceChange(userID, productID, oldProduct.Price, product.Price)
 
 		c.JSON(http.StatusOK, gin.H{"message": "Product updated partially successfully"})
 	}
@@ -281,11 +554,14 @@ func SearchByBarcodePrefix() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		barcodePrefix := c.Query("barcode")
+		barcodePrefix := regexp.QuoteMeta(c.Query("barcode"))
 
 		var products []models.Product
 
-		cursor, err := productCollection.Find(ctx, bson.M{"barcode": bson.M{"$regex": "^" + barcodePrefix}})
+		cursor, err := productCollection.Find(ctx, bson.M{"$or": []bson.M{
+			{"barcode": bson.M{"$regex": "^" + barcodePrefix}},
+			{"units.barcode": bson.M{"$regex": "^" + barcodePrefix}},
+		}})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding products"})
@@ -308,3 +584,32 @@ func SearchByBarcodePrefix() gin.HandlerFunc {
 		c.JSON(http.StatusOK, products)
 	}
 }
+
+func SearchProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		recordPerPage, recordPageErr := strconv.Atoi(c.Query("recordPerPage"))
+		if recordPageErr != nil || recordPerPage < 1 {
+			recordPerPage = 10
+		}
+
+		page, pageErr := strconv.Atoi(c.Query("page"))
+		if pageErr != nil || page < 1 {
+			page = 1
+		}
+
+		result, err := helper.SearchProducts(ctx, c.Query("q"), page, recordPerPage)
+		if err != nil {
+			if errors.Is(err, helper.ErrEmptyQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while searching products"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
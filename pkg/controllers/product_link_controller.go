@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetProductLinks lists the products a product links to with their stock.
+func GetProductLinks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var product models.Product
+
+		err := productCollection.FindOne(ctx, bson.M{"productid": c.Param("productid")},
+			options.FindOne().SetProjection(bson.M{"productid": 1, "links": 1})).Decode(&product)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+
+		linked, err := helper.LinkedProducts(ctx, product)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while looking up linked products"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linked)
+	}
+}
+
+// AddProductLink links a product to a substitute, an accessory or the product superseding it.
+func AddProductLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var link models.ProductLink
+
+		if err := c.BindJSON(&link); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(link)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		link, err := helper.AddProductLink(ctx, c.GetString("userid"), c.Param("productid"), link)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if errors.Is(err, helper.ErrInvalidLink) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, helper.ErrLinkExists) || errors.Is(err, helper.ErrTooManyLinks) ||
+			errors.Is(err, helper.ErrAlreadySuperseded) || errors.Is(err, helper.ErrProductArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while linking products"})
+			return
+		}
+
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// RemoveProductLink removes a link of a type from a product to another one.
+func RemoveProductLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := helper.RemoveProductLink(ctx, c.Param("productid"), c.Param("type"), c.Param("linkedid"))
+
+		if errors.Is(err, helper.ErrLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, helper.ErrProductArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while removing link"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Link removed successfully"})
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/valuation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func GetInventoryValuation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		method, err := valuation.ParseMethod(c.Query("method"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var from, to time.Time
+		if value := c.Query("from"); value != "" {
+			if from, err = helper.ParseDate(value, false); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if value := c.Query("to"); value != "" {
+			if to, err = helper.ParseDate(value, true); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		productFilter := bson.M{}
+		if value := c.Query("category"); value != "" {
+			category, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Category must be a number"})
+				return
+			}
+			productFilter["category"] = category
+		}
+		if productID := c.Query("productid"); productID != "" {
+			productFilter["productid"] = productID
+		}
+
+		report, err := helper.ValueInventory(ctx, productFilter, method, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while valuing inventory"})
+			return
+		}
+
+		if c.Query("format") == "csv" || strings.Contains(c.GetHeader("Accept"), "text/csv") {
+			writeValuationCSV(c, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func writeValuationCSV(c *gin.Context, report helper.ValuationReport) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename=valuation-"+string(report.Method)+".csv")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{
+		"level", "productid", "barcode", "name", "category",
+		"openingquantity", "openingvalue", "receivedquantity", "receivedvalue",
+		"issuedquantity", "issuedcost", "closingquantity", "closingvalue", "currency",
+	})
+
+	write := func(level string, line helper.ValuationLine) {
+		writer.Write([]string{
+			level, line.ProductID, line.Barcode, line.Name, strconv.Itoa(line.Category),
+			strconv.FormatUint(uint64(line.OpeningQuantity), 10), line.OpeningValue.Amount.String(),
+			strconv.FormatUint(uint64(line.ReceivedQuantity), 10), line.ReceivedValue.Amount.String(),
+			strconv.FormatUint(uint64(line.IssuedQuantity), 10), line.IssuedCost.Amount.String(),
+			strconv.FormatUint(uint64(line.ClosingQuantity), 10), line.ClosingValue.Amount.String(),
+			report.Currency,
+		})
+	}
+	for _, line := range report.Products {
+		write("product", line)
+	}
+	for _, line := range report.Categories {
+		write("category", line)
+	}
+	write("total", report.Total)
+
+	writer.Flush()
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var savedSearchCollection *mongo.Collection = database.OpenCollection(database.Client, "savedsearch")
+
+// visibleSearches matches the saved searches of the user and the ones shared by others.
+func visibleSearches(c *gin.Context) bson.M {
+	return bson.M{"$or": []bson.M{{"ownerid": c.GetString("userid")}, {"shared": true}}}
+}
+
+func CreateSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var search models.SavedSearch
+
+		if err := c.BindJSON(&search); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(search)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := helper.ValidateSavedSearch(search); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		search.ID = primitive.NewObjectID()
+		search.SavedSearchID = search.ID.Hex()
+		search.OwnerID = c.GetString("userid")
+		search.OwnerEmail = c.GetString("email")
+		search.ResultIDs = nil
+		search.CheckedAt = time.Time{}
+		search.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		search.UpdatedAt = search.CreatedAt
+
+		_, insertErr := savedSearchCollection.InsertOne(ctx, search)
+		if insertErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while saving search"})
+			return
+		}
+
+		c.JSON(http.StatusOK, search)
+	}
+}
+
+func GetSavedSearches() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		cursor, err := savedSearchCollection.Find(ctx, visibleSearches(c), options.Find().SetSort(bson.M{"name": 1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding saved searches"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		searches := []models.SavedSearch{}
+		if err = cursor.All(ctx, &searches); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding saved searches"})
+			return
+		}
+
+		c.JSON(http.StatusOK, searches)
+	}
+}
+
+func GetSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var search models.SavedSearch
+
+		filter := visibleSearches(c)
+		filter["savedsearchid"] = c.Param("searchid")
+		if err := savedSearchCollection.FindOne(ctx, filter).Decode(&search); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, search)
+	}
+}
+
+func UpdateSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var search models.SavedSearch
+
+		if err := c.BindJSON(&search); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(search)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := helper.ValidateSavedSearch(search); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		// Changing the search starts the digest afresh.
+		update := bson.M{"$set": bson.M{
+			"name":          search.Name,
+			"filters":       search.Filters,
+			"sort":          search.Sort,
+			"recordperpage": search.RecordPerPage,
+			"shared":        search.Shared,
+			"digest":        search.Digest,
+			"resultids":     nil,
+			"checkedat":     time.Time{},
+			"updatedat":     updatedAt,
+		}}
+
+		var updated models.SavedSearch
+		err := savedSearchCollection.FindOneAndUpdate(ctx,
+			bson.M{"savedsearchid": c.Param("searchid"), "ownerid": c.GetString("userid")},
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"savedsearchid": c.Param("searchid")}
+		if helper.CheckUserType(c, "ADMIN") != nil {
+			filter["ownerid"] = c.GetString("userid")
+		}
+
+		result, err := savedSearchCollection.DeleteOne(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting saved search"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+	}
+}
+
+// RunSavedSearch answers like the product list called with the parameters of the saved
+// search. The page can be chosen with the page query parameter.
+func RunSavedSearch() gin.HandlerFunc {
+	listProducts := GetProducts()
+
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var search models.SavedSearch
+
+		filter := visibleSearches(c)
+		filter["savedsearchid"] = c.Param("searchid")
+		if err := savedSearchCollection.FindOne(ctx, filter).Decode(&search); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return
+		}
+
+		page, _ := strconv.Atoi(c.Query("page"))
+		query := helper.SavedSearchQuery(search, page)
+		if facets := c.Query("facets"); facets != "" {
+			query.Set("facets", facets)
+		}
+		c.Request.URL.RawQuery = query.Encode()
+
+		listProducts(c)
+	}
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/analytics"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatsOverview returns the dashboard figures. The low stock threshold is read from
+// "lowstock" and the number of top movers from "top".
+func GetStatsOverview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		lowStock := uint(helper.DefaultLowStock)
+		if value := c.Query("lowstock"); value != "" {
+			number, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || number > helper.MaxLowStock {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "lowstock must be a whole number between 0 and " + strconv.Itoa(helper.MaxLowStock)})
+				return
+			}
+			lowStock = uint(number)
+		}
+
+		top := helper.DefaultTopMovers
+		if value := c.Query("top"); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 1 || number > helper.MaxTopMovers {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "top must be a number between 1 and " + strconv.Itoa(helper.MaxTopMovers)})
+				return
+			}
+			top = number
+		}
+
+		overview, err := helper.StatsOverviewFor(ctx, lowStock, top)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while computing statistics"})
+			return
+		}
+
+		c.JSON(http.StatusOK, overview)
+	}
+}
+
+// GetMovementAnalytics returns the inbound and outbound quantities and the number of
+// movements per "interval" from "from" through "to", by default the last 30 intervals.
+// "productid", "category" (a number or a name) and "userid" narrow the movements counted.
+func GetMovementAnalytics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		interval, err := analytics.ParseInterval(c.Query("interval"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		to := time.Now().UTC()
+		if value := c.Query("to"); value != "" {
+			if to, err = helper.ParseDate(value, true); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		from := analytics.Back(to, interval, helper.DefaultAnalyticsBuckets-1)
+		if value := c.Query("from"); value != "" {
+			if from, err = helper.ParseDate(value, false); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		filter := helper.MovementFilter{ProductID: c.Query("productid"), UserID: c.Query("userid")}
+		if value := c.Query("category"); value != "" {
+			category, err := types.ParseCategory(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			number := int(category)
+			filter.Category = &number
+		}
+
+		series, err := helper.MovementAnalytics(ctx, filter, interval, from, to)
+		if errors.Is(err, analytics.ErrInvalidRange) || errors.Is(err, analytics.ErrTooManyBuckets) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while computing movement analytics"})
+			return
+		}
+
+		c.JSON(http.StatusOK, series)
+	}
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ReceiveStock() gin.HandlerFunc {
+	return moveStock(types.Receive)
+}
+
+func IssueStock() gin.HandlerFunc {
+	return moveStock(types.Issue)
+}
+
+func moveStock(processtype types.ProcessTypes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		productID := c.Param("productid")
+
+		var movement models.StockMovement
+
+		if err := c.BindJSON(&movement); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(movement)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		var product models.Product
+
+		err := productCollection.FindOne(ctx, bson.M{"productid": productID}).Decode(&product)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if product.BaseUnit == "" {
+			product.BaseUnit = helper.DefaultBaseUnit
+		}
+		switch helper.ProductStatus(product) {
+		case types.ProductArchived:
+			c.JSON(http.StatusConflict, gin.H{"error": helper.ErrProductArchived.Error()})
+			return
+		case types.ProductDiscontinued:
+			if processtype == types.Receive {
+				c.JSON(http.StatusConflict, gin.H{"error": helper.ErrProductDiscontinued.Error()})
+				return
+			}
+		}
+
+		unit, err := helper.ResolveUnit(product, movement)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID := c.GetString("userid")
+		if product.Kind == string(types.Bundle) {
+			if processtype != types.Issue {
+				c.JSON(http.StatusBadRequest, gin.H{"error": helper.ErrBundleStock.Error()})
+				return
+			}
+			err = helper.IssueBundle(ctx, userID, product, unit, movement)
+		} else {
+			err = helper.MoveStock(ctx, userID, product, unit, movement, processtype)
+		}
+
+		if errors.Is(err, helper.ErrInsufficientStock) {
+			message := "Not enough stock to issue"
+			if product.Kind == string(types.Bundle) {
+				message += ": " + err.Error()
+			}
+			response := gin.H{"error": message}
+			if quantity, err := helper.ToBaseQuantity(unit, movement.Quantity); err == nil {
+				if substitutes, err := helper.InStockSubstitutes(ctx, product, quantity); err == nil && len(substitutes) > 0 {
+					response["substitutes"] = substitutes
+				}
+			}
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+		if errors.Is(err, helper.ErrProductArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, helper.ErrQuantityTooLarge) || errors.Is(err, helper.ErrInvalidPrice) || errors.Is(err, helper.ErrNoExchangeRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while updating stock"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully"})
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var transactionCollection *mongo.Collection = database.OpenCollection(database.Client, "transaction")
+
+func GetTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		recordPerPage, recordPageErr := strconv.Atoi(c.Query("recordPerPage"))
+		if recordPageErr != nil || recordPerPage < 1 {
+			recordPerPage = 20
+		}
+
+		page, pageErr := strconv.Atoi(c.Query("page"))
+		if pageErr != nil || page < 1 {
+			page = 1
+		}
+
+		filter, err := helper.TransactionFilter(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		totalCount, err := transactionCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting transactions"})
+			return
+		}
+
+		opts := options.Find().
+			SetSort(bson.D{{Key: "processtime", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(int64((page - 1) * recordPerPage)).
+			SetLimit(int64(recordPerPage))
+
+		cursor, err := transactionCollection.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding transactions"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		transactions := []models.Transaction{}
+		if err = cursor.All(ctx, &transactions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding transactions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"transactionItems": transactions,
+			"totalCount":       totalCount,
+			"totalPages":       (totalCount + int64(recordPerPage) - 1) / int64(recordPerPage),
+			"currentPage":      page,
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/database"
+	helper "github.com/Deatsilence/go-stocket/helpers"
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/valuation"
+	"github.com/Deatsilence/go-stocket/types"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var workOrderCollection *mongo.Collection = database.OpenCollection(database.Client, "workorder")
+
+func CreateWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.WorkOrder
+
+		if err := c.BindJSON(&order); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(order)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := helper.ValidateWorkOrder(ctx, order); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		for i := range order.Components {
+			order.Components[i].Cost = nil
+		}
+		order.ID = primitive.NewObjectID()
+		order.WorkOrderID = order.ID.Hex()
+		order.Status = string(types.WorkOrderPlanned)
+		order.Method = ""
+		order.UnitCost = nil
+		order.TotalCost = nil
+		order.CreatedBy = c.GetString("userid")
+		order.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		order.StartedBy, order.StartedAt = "", time.Time{}
+		order.CompletedBy, order.CompletedAt = "", time.Time{}
+		order.UpdatedAt = order.CreatedAt
+
+		_, insertErr := workOrderCollection.InsertOne(ctx, order)
+		if insertErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while creating work order"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+func GetWorkOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+		if productID := c.Query("productid"); productID != "" {
+			filter["productid"] = productID
+		}
+
+		cursor, err := workOrderCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"createdat": -1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding work orders"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		orders := []models.WorkOrder{}
+		if err = cursor.All(ctx, &orders); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding work orders"})
+			return
+		}
+
+		c.JSON(http.StatusOK, orders)
+	}
+}
+
+func GetWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var order models.WorkOrder
+
+		err := workOrderCollection.FindOne(ctx, bson.M{"workorderid": c.Param("workorderid")}).Decode(&order)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Work order not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// UpdateWorkOrder replaces the product, quantity, components and note of a planned work order.
+func UpdateWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.WorkOrder
+
+		if err := c.BindJSON(&order); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validateProduct.Struct(order)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := helper.ValidateWorkOrder(ctx, order); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range order.Components {
+			order.Components[i].Cost = nil
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		var updated models.WorkOrder
+		err := workOrderCollection.FindOneAndUpdate(ctx,
+			bson.M{"workorderid": c.Param("workorderid"), "status": string(types.WorkOrderPlanned)},
+			bson.M{"$set": bson.M{
+				"productid":  order.ProductID,
+				"quantity":   order.Quantity,
+				"components": order.Components,
+				"note":       order.Note,
+				"updatedat":  updatedAt,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planned work order not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// StartWorkOrder moves a planned work order to in progress.
+func StartWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.WorkOrder
+		err := workOrderCollection.FindOne(ctx,
+			bson.M{"workorderid": c.Param("workorderid"), "status": string(types.WorkOrderPlanned)},
+		).Decode(&order)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planned work order not found"})
+			return
+		}
+		// The products may have been archived or discontinued since the order was planned.
+		if err := helper.ValidateWorkOrder(ctx, order); err != nil {
+			if errors.Is(err, helper.ErrProductArchived) || errors.Is(err, helper.ErrProductDiscontinued) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		startedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		err = workOrderCollection.FindOneAndUpdate(ctx,
+			bson.M{"workorderid": c.Param("workorderid"), "status": string(types.WorkOrderPlanned)},
+			bson.M{"$set": bson.M{
+				"status":    string(types.WorkOrderInProgress),
+				"startedby": c.GetString("userid"),
+				"startedat": startedAt,
+				"updatedat": startedAt,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&order)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planned work order not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// CompleteWorkOrder posts the consumption and production of a work order in progress.
+// The components are costed with the method query parameter, FIFO by default.
+func CompleteWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		method, err := valuation.ParseMethod(c.Query("method"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		order, err := helper.CompleteWorkOrder(ctx, c.Param("workorderid"), c.GetString("userid"), method)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Work order not found"})
+			return
+		}
+		if errors.Is(err, helper.ErrWorkOrderStatus) || errors.Is(err, helper.ErrInsufficientStock) ||
+			errors.Is(err, helper.ErrProductArchived) || errors.Is(err, helper.ErrProductDiscontinued) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while completing work order"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// DeleteWorkOrder removes a work order that has not been started.
+func DeleteWorkOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := helper.CheckUserType(c, "ADMIN"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := workOrderCollection.DeleteOne(ctx, bson.M{"workorderid": c.Param("workorderid"), "status": string(types.WorkOrderPlanned)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occured while deleting work order"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Planned work order not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Work order deleted successfully"})
+	}
+}
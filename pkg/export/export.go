@@ -0,0 +1,149 @@
+// Package export streams records as CSV, a JSON array or newline-delimited JSON, one
+// record at a time, so a whole result set never has to be held in memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+)
+
+// Negotiate picks the format from a format query parameter, which wins, or from the
+// Accept header. JSON is the default.
+func Negotiate(query string, accept string) (Format, error) {
+	switch strings.ToLower(query) {
+	case "csv":
+		return CSV, nil
+	case "json":
+		return JSON, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format %q, use csv, json or ndjson", query)
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+		switch mediaType {
+		case "text/csv":
+			return CSV, nil
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return NDJSON, nil
+		case "application/json":
+			return JSON, nil
+		}
+	}
+	return JSON, nil
+}
+
+// ContentType is the media type sent with the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Writer writes records in one format. JSON formats encode the record itself, CSV
+// writes the row given alongside it under the header.
+type Writer struct {
+	format  Format
+	out     io.Writer
+	csv     *csv.Writer
+	header  []string
+	written int
+}
+
+func NewWriter(out io.Writer, format Format, header []string) *Writer {
+	w := &Writer{format: format, out: out, header: header}
+	if format == CSV {
+		w.csv = csv.NewWriter(out)
+	}
+	return w
+}
+
+// Write writes one record.
+func (w *Writer) Write(record interface{}, row []string) error {
+	defer func() { w.written++ }()
+
+	switch w.format {
+	case CSV:
+		if w.written == 0 {
+			if err := w.csv.Write(w.header); err != nil {
+				return err
+			}
+		}
+		return w.csv.Write(row)
+	case NDJSON:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		_, err = w.out.Write(append(data, '\n'))
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	separator := ","
+	if w.written == 0 {
+		separator = "["
+	}
+	if _, err := io.WriteString(w.out, separator); err != nil {
+		return err
+	}
+	_, err = w.out.Write(data)
+	return err
+}
+
+// Flush pushes buffered CSV rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+// Close finishes the document, writing the CSV header or an empty array when there
+// were no records.
+func (w *Writer) Close() error {
+	switch w.format {
+	case CSV:
+		if w.written == 0 {
+			if err := w.csv.Write(w.header); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	case JSON:
+		closing := "]"
+		if w.written == 0 {
+			closing = "[]"
+		}
+		_, err := io.WriteString(w.out, closing)
+		return err
+	}
+	return nil
+}
+
+// Written is the number of records written so far.
+func (w *Writer) Written() int {
+	return w.written
+}
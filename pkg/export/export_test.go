@@ -0,0 +1,51 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func write(t *testing.T, format Format, items []item) string {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, format, []string{"name"})
+	for _, i := range items {
+		assert.NoError(t, w.Write(i, []string{i.Name}))
+	}
+	assert.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestFormats(t *testing.T) {
+	items := []item{{Name: "pen"}, {Name: "ink, blue"}}
+
+	assert.Equal(t, "name\npen\n\"ink, blue\"\n", write(t, CSV, items))
+	assert.Equal(t, "{\"name\":\"pen\"}\n{\"name\":\"ink, blue\"}\n", write(t, NDJSON, items))
+
+	var decoded []item
+	assert.NoError(t, json.Unmarshal([]byte(write(t, JSON, items)), &decoded))
+	assert.Equal(t, items, decoded)
+
+	assert.Equal(t, "[]", write(t, JSON, nil))
+	assert.Equal(t, "name\n", write(t, CSV, nil))
+}
+
+func TestNegotiate(t *testing.T) {
+	format, _ := Negotiate("", "text/csv")
+	assert.Equal(t, CSV, format)
+
+	format, _ = Negotiate("ndjson", "text/csv")
+	assert.Equal(t, NDJSON, format)
+
+	format, _ = Negotiate("", "*/*")
+	assert.Equal(t, JSON, format)
+
+	_, err := Negotiate("xml", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+// Package forecast predicts daily demand from past demand and works out when and how
+// much to reorder.
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Method string
+
+const (
+	Auto          Method = "auto"
+	MovingAverage Method = "average"
+	Smoothing     Method = "smoothing"
+	Seasonal      Method = "seasonal"
+)
+
+const (
+	// Window is the number of days the moving average is taken over.
+	Window = 28
+	// Alpha is the smoothing factor of the level.
+	Alpha = 0.3
+	// Gamma is the smoothing factor of the seasonal indices.
+	Gamma = 0.1
+	// SeasonLength is the length of the demand cycle in days, a week.
+	SeasonLength = 7
+	// MinSeasonalHistory is the history needed before weekly seasonality is modelled.
+	MinSeasonalHistory = 4 * SeasonLength
+)
+
+var (
+	ErrHorizon           = errors.New("the horizon must be at least one day")
+	ErrNotEnoughHistory  = fmt.Errorf("seasonal forecasts need at least %d days of history", MinSeasonalHistory)
+	ErrServiceLevel      = errors.New("the service level must be at least 0.5 and below 1")
+	ErrNegativeLeadTime  = errors.New("the lead time cannot be negative")
+	ErrNegativeCoverDays = errors.New("the cover days cannot be negative")
+)
+
+// ParseMethod reads a forecasting method name, defaulting to Auto when empty.
+func ParseMethod(name string) (Method, error) {
+	switch Method(strings.ToLower(name)) {
+	case "", Auto:
+		return Auto, nil
+	case MovingAverage, "movingaverage", "ma":
+		return MovingAverage, nil
+	case Smoothing, "exponential", "ses":
+		return Smoothing, nil
+	case Seasonal, "holtwinters":
+		return Seasonal, nil
+	}
+	return "", fmt.Errorf("unknown forecasting method %q", name)
+}
+
+// Result is a forecast of the daily demand. Error is the root mean square of the
+// one-day-ahead errors over the history, used as the spread of the daily demand.
+type Result struct {
+	Method Method    `json:"method"`
+	Daily  []float64 `json:"daily"`
+	Total  float64   `json:"total"`
+	Error  float64   `json:"error"`
+}
+
+// Forecast predicts the demand of the horizon days following the history, which holds
+// the demand of consecutive days, oldest first. Auto uses the seasonal method when the
+// history is long enough and exponential smoothing otherwise.
+func Forecast(history []float64, method Method, horizon int) (Result, error) {
+	if horizon < 1 {
+		return Result{}, ErrHorizon
+	}
+	if method == Auto {
+		method = Smoothing
+		if len(history) >= MinSeasonalHistory {
+			method = Seasonal
+		}
+	}
+
+	var daily, errs []float64
+	switch method {
+	case MovingAverage:
+		daily, errs = movingAverage(history, horizon)
+	case Smoothing:
+		daily, errs = smoothing(history, horizon)
+	case Seasonal:
+		if len(history) < MinSeasonalHistory {
+			return Result{}, ErrNotEnoughHistory
+		}
+		daily, errs = seasonal(history, horizon)
+	default:
+		return Result{}, fmt.Errorf("unknown forecasting method %q", method)
+	}
+
+	result := Result{Method: method, Daily: daily, Error: rms(errs)}
+	for i, demand := range daily {
+		// Demand cannot be negative, whatever the model says.
+		if demand < 0 {
+			daily[i] = 0
+		}
+		result.Total += daily[i]
+	}
+	return result, nil
+}
+
+func movingAverage(history []float64, horizon int) ([]float64, []float64) {
+	mean := func(values []float64) float64 {
+		if len(values) == 0 {
+			return 0
+		}
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	}
+
+	var errs []float64
+	for t := 1; t < len(history); t++ {
+		errs = append(errs, history[t]-mean(history[maxInt(0, t-Window):t]))
+	}
+	return repeat(mean(history[maxInt(0, len(history)-Window):]), horizon), errs
+}
+
+func smoothing(history []float64, horizon int) ([]float64, []float64) {
+	if len(history) == 0 {
+		return repeat(0, horizon), nil
+	}
+	var errs []float64
+	level := history[0]
+	for _, demand := range history[1:] {
+		err := demand - level
+		errs = append(errs, err)
+		level += Alpha * err
+	}
+	return repeat(level, horizon), errs
+}
+
+// seasonal is exponential smoothing of a level with additive weekly indices.
+func seasonal(history []float64, horizon int) ([]float64, []float64) {
+	level := 0.0
+	for _, demand := range history[:SeasonLength] {
+		level += demand
+	}
+	level /= SeasonLength
+	indices := make([]float64, SeasonLength)
+	for i := range indices {
+		indices[i] = history[i] - level
+	}
+
+	var errs []float64
+	for t := SeasonLength; t < len(history); t++ {
+		i := t % SeasonLength
+		errs = append(errs, history[t]-(level+indices[i]))
+		next := Alpha*(history[t]-indices[i]) + (1-Alpha)*level
+		indices[i] = Gamma*(history[t]-next) + (1-Gamma)*indices[i]
+		level = next
+	}
+
+	daily := make([]float64, horizon)
+	for k := range daily {
+		daily[k] = level + indices[(len(history)+k)%SeasonLength]
+	}
+	return daily, errs
+}
+
+func repeat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+func rms(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value * value
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func constant(value float64, days int) []float64 {
+	return repeat(value, days)
+}
+
+// weekly repeats a week of demand, busy on the weekend.
+func weekly(weeks int) []float64 {
+	week := []float64{2, 2, 2, 2, 2, 10, 10}
+	var history []float64
+	for i := 0; i < weeks; i++ {
+		history = append(history, week...)
+	}
+	return history
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := ParseMethod("")
+	assert.NoError(t, err)
+	assert.Equal(t, Auto, method)
+
+	method, err = ParseMethod("SES")
+	assert.NoError(t, err)
+	assert.Equal(t, Smoothing, method)
+
+	_, err = ParseMethod("arima")
+	assert.Error(t, err)
+}
+
+func TestForecastConstantDemand(t *testing.T) {
+	for _, method := range []Method{MovingAverage, Smoothing, Seasonal} {
+		result, err := Forecast(constant(4, 35), method, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, method, result.Method)
+		assert.Len(t, result.Daily, 10)
+		assert.InDelta(t, 40, result.Total, 1e-9, method)
+		assert.InDelta(t, 0, result.Error, 1e-9, method)
+	}
+}
+
+func TestForecastMovingAverageUsesWindow(t *testing.T) {
+	history := append(constant(100, 30), constant(1, Window)...)
+	result, err := Forecast(history, MovingAverage, 3)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{1, 1, 1}, result.Daily, 1e-9)
+}
+
+func TestForecastSmoothingFollowsChange(t *testing.T) {
+	result, err := Forecast(append(constant(0, 10), constant(10, 10)...), Smoothing, 1)
+	assert.NoError(t, err)
+	assert.Greater(t, result.Daily[0], 9.0)
+	assert.Less(t, result.Daily[0], 10.0)
+	assert.Greater(t, result.Error, 0.0)
+}
+
+func TestForecastSeasonal(t *testing.T) {
+	result, err := Forecast(weekly(6), Seasonal, 7)
+	assert.NoError(t, err)
+	// The history ends on a full week, so the forecast starts on its first day.
+	assert.InDeltaSlice(t, []float64{2, 2, 2, 2, 2, 10, 10}, result.Daily, 1e-9)
+	assert.InDelta(t, 0, result.Error, 1e-9)
+
+	flat, err := Forecast(weekly(6), Smoothing, 7)
+	assert.NoError(t, err)
+	assert.Greater(t, flat.Error, result.Error)
+}
+
+func TestForecastAuto(t *testing.T) {
+	result, err := Forecast(weekly(4), Auto, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Seasonal, result.Method)
+
+	result, err = Forecast(weekly(3), Auto, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Smoothing, result.Method)
+
+	_, err = Forecast(weekly(3), Seasonal, 1)
+	assert.ErrorIs(t, err, ErrNotEnoughHistory)
+}
+
+func TestForecastEdges(t *testing.T) {
+	_, err := Forecast(constant(1, 10), Smoothing, 0)
+	assert.ErrorIs(t, err, ErrHorizon)
+
+	for _, method := range []Method{Auto, MovingAverage, Smoothing} {
+		result, err := Forecast(nil, method, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, result.Total)
+		assert.Len(t, result.Daily, 5)
+	}
+
+	// A falling level with a strong weekly swing would go below zero.
+	history := append(weekly(4), constant(0, 14)...)
+	result, err := Forecast(history, Seasonal, 14)
+	assert.NoError(t, err)
+	for _, demand := range result.Daily {
+		assert.GreaterOrEqual(t, demand, 0.0)
+	}
+}
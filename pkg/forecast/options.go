@@ -0,0 +1,83 @@
+package forecast
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	DefaultHorizon      = 30
+	MaxHorizon          = 365
+	DefaultHistoryDays  = 90
+	MaxHistoryDays      = 730
+	DefaultLeadTimeDays = 7
+	DefaultCoverDays    = 30
+	DefaultServiceLevel = 0.95
+)
+
+// Options are the forecasting method, the days forecast, the days of history read from
+// the ledger and the reorder policy.
+type Options struct {
+	Method      Method
+	Horizon     int
+	HistoryDays int
+	Policy      Policy
+}
+
+// OptionsFrom reads "method", "horizon", "history", "leadtime", "cover" and
+// "servicelevel" from the query string.
+func OptionsFrom(query url.Values) (Options, error) {
+	opts := Options{
+		Horizon:     DefaultHorizon,
+		HistoryDays: DefaultHistoryDays,
+		Policy: Policy{
+			LeadTimeDays: DefaultLeadTimeDays,
+			CoverDays:    DefaultCoverDays,
+			ServiceLevel: DefaultServiceLevel,
+		},
+	}
+
+	method, err := ParseMethod(query.Get("method"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Method = method
+
+	days := func(name string, target *int, min int, max int) error {
+		value := query.Get(name)
+		if value == "" {
+			return nil
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < min || number > max {
+			return fmt.Errorf("%s must be a number between %d and %d", name, min, max)
+		}
+		*target = number
+		return nil
+	}
+	if err := days("horizon", &opts.Horizon, 1, MaxHorizon); err != nil {
+		return opts, err
+	}
+	if err := days("history", &opts.HistoryDays, 1, MaxHistoryDays); err != nil {
+		return opts, err
+	}
+	if err := days("leadtime", &opts.Policy.LeadTimeDays, 0, MaxHorizon); err != nil {
+		return opts, err
+	}
+	if err := days("cover", &opts.Policy.CoverDays, 0, MaxHorizon); err != nil {
+		return opts, err
+	}
+
+	if value := query.Get("servicelevel"); value != "" {
+		level, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return opts, ErrServiceLevel
+		}
+		opts.Policy.ServiceLevel = level
+	}
+	if _, err := ServiceFactor(opts.Policy.ServiceLevel); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
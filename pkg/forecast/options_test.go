@@ -0,0 +1,52 @@
+package forecast
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsFromDefaults(t *testing.T) {
+	opts, err := OptionsFrom(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, Options{
+		Method:      Auto,
+		Horizon:     DefaultHorizon,
+		HistoryDays: DefaultHistoryDays,
+		Policy:      Policy{LeadTimeDays: DefaultLeadTimeDays, CoverDays: DefaultCoverDays, ServiceLevel: DefaultServiceLevel},
+	}, opts)
+}
+
+func TestOptionsFrom(t *testing.T) {
+	opts, err := OptionsFrom(url.Values{
+		"method": {"Seasonal"}, "horizon": {"14"}, "history": {"60"},
+		"leadtime": {"0"}, "cover": {"10"}, "servicelevel": {"0.99"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Options{
+		Method:      Seasonal,
+		Horizon:     14,
+		HistoryDays: 60,
+		Policy:      Policy{LeadTimeDays: 0, CoverDays: 10, ServiceLevel: 0.99},
+	}, opts)
+}
+
+func TestOptionsFromErrors(t *testing.T) {
+	for _, query := range []url.Values{
+		{"method": {"guess"}},
+		{"horizon": {"0"}},
+		{"horizon": {"366"}},
+		{"history": {"abc"}},
+		{"leadtime": {"-1"}},
+		{"cover": {"366"}},
+		{"servicelevel": {"high"}},
+		{"servicelevel": {"1"}},
+	} {
+		_, err := OptionsFrom(query)
+		assert.Error(t, err, query.Encode())
+	}
+
+	_, err := OptionsFrom(url.Values{"servicelevel": {"0.4"}})
+	assert.ErrorIs(t, err, ErrServiceLevel)
+}
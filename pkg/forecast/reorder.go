@@ -0,0 +1,88 @@
+package forecast
+
+import "math"
+
+// Policy is how a product is replenished. An order arrives LeadTimeDays after it is
+// placed and should cover the demand of CoverDays after that. ServiceLevel is the wanted
+// probability of not running out during the lead time, such as 0.95.
+type Policy struct {
+	LeadTimeDays int
+	CoverDays    int
+	ServiceLevel float64
+}
+
+// Suggestion is when and how much to reorder. Quantity is zero while the stock is above
+// the reorder point.
+type Suggestion struct {
+	LeadTimeDemand float64 `json:"leadtimedemand"`
+	SafetyStock    float64 `json:"safetystock"`
+	ReorderPoint   float64 `json:"reorderpoint"`
+	Quantity       uint    `json:"quantity"`
+}
+
+// ServiceFactor is the number of standard deviations of safety stock that gives the
+// service level under normally distributed demand.
+func ServiceFactor(level float64) (float64, error) {
+	if level < 0.5 || level >= 1 {
+		return 0, ErrServiceLevel
+	}
+	return math.Sqrt2 * math.Erfinv(2*level-1), nil
+}
+
+// Reorder suggests a reorder for the stock on hand from a forecast. The safety stock
+// grows with the forecast error and the square root of the lead time. An order brings
+// the stock up to the demand of the lead time and the cover days plus the safety stock.
+func Reorder(result Result, stock uint, policy Policy) (Suggestion, error) {
+	if policy.LeadTimeDays < 0 {
+		return Suggestion{}, ErrNegativeLeadTime
+	}
+	if policy.CoverDays < 0 {
+		return Suggestion{}, ErrNegativeCoverDays
+	}
+	z, err := ServiceFactor(policy.ServiceLevel)
+	if err != nil {
+		return Suggestion{}, err
+	}
+
+	suggestion := Suggestion{
+		LeadTimeDemand: Demand(result, 0, policy.LeadTimeDays),
+		SafetyStock:    z * result.Error * math.Sqrt(float64(policy.LeadTimeDays)),
+	}
+	suggestion.ReorderPoint = suggestion.LeadTimeDemand + suggestion.SafetyStock
+
+	if float64(stock) <= suggestion.ReorderPoint {
+		target := Demand(result, 0, policy.LeadTimeDays+policy.CoverDays) + suggestion.SafetyStock
+		if needed := math.Ceil(target - float64(stock)); needed > 0 {
+			suggestion.Quantity = uint(needed)
+		}
+	}
+	return suggestion, nil
+}
+
+// Demand sums the forecast demand of days from through to-1, counted from the first
+// forecast day. Days past the end of the forecast take the demand of its last day.
+func Demand(result Result, from int, to int) float64 {
+	sum := 0.0
+	for day := from; day < to; day++ {
+		switch {
+		case day < len(result.Daily):
+			sum += result.Daily[day]
+		case len(result.Daily) > 0:
+			sum += result.Daily[len(result.Daily)-1]
+		}
+	}
+	return sum
+}
+
+// StockOut returns the first forecast day, counted from zero, on which the demand since
+// the first day exceeds the stock, and false when the stock lasts the whole forecast.
+func StockOut(result Result, stock uint) (int, bool) {
+	cumulative := 0.0
+	for day, demand := range result.Daily {
+		cumulative += demand
+		if cumulative > float64(stock) {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,64 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFactor(t *testing.T) {
+	z, err := ServiceFactor(0.95)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.6449, z, 1e-4)
+
+	z, err = ServiceFactor(0.5)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, z, 1e-9)
+
+	_, err = ServiceFactor(1)
+	assert.ErrorIs(t, err, ErrServiceLevel)
+	_, err = ServiceFactor(0.4)
+	assert.ErrorIs(t, err, ErrServiceLevel)
+}
+
+func TestReorder(t *testing.T) {
+	result := Result{Daily: constant(5, 10), Total: 50, Error: 2}
+	policy := Policy{LeadTimeDays: 4, CoverDays: 10, ServiceLevel: 0.95}
+
+	suggestion, err := Reorder(result, 30, policy)
+	assert.NoError(t, err)
+	assert.InDelta(t, 20, suggestion.LeadTimeDemand, 1e-9)
+	// 1.6449 * 2 * sqrt(4)
+	assert.InDelta(t, 6.5794, suggestion.SafetyStock, 1e-4)
+	assert.InDelta(t, 26.5794, suggestion.ReorderPoint, 1e-4)
+	assert.Equal(t, uint(0), suggestion.Quantity)
+
+	// Below the reorder point the order covers 14 days of demand and the safety stock;
+	// the days past the forecast take the demand of its last day.
+	suggestion, err = Reorder(result, 20, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(57), suggestion.Quantity)
+
+	_, err = Reorder(result, 20, Policy{LeadTimeDays: -1, ServiceLevel: 0.95})
+	assert.ErrorIs(t, err, ErrNegativeLeadTime)
+	_, err = Reorder(result, 20, Policy{LeadTimeDays: 1, CoverDays: -1, ServiceLevel: 0.95})
+	assert.ErrorIs(t, err, ErrNegativeCoverDays)
+}
+
+func TestStockOut(t *testing.T) {
+	result := Result{Daily: []float64{2, 2, 2, 2}}
+
+	day, ok := StockOut(result, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 2, day)
+
+	_, ok = StockOut(result, 8)
+	assert.False(t, ok)
+
+	day, ok = StockOut(result, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 0, day)
+
+	_, ok = StockOut(Result{Daily: []float64{0, 0}}, 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,218 @@
+// Package importer reads product rows from CSV and XLSX files and maps their columns
+// onto product fields.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// Fields are the product fields a column can be mapped to.
+var Fields = []string{"barcode", "name", "description", "category", "price", "currency", "stock", "baseunit", "location"}
+
+// Mapping maps a product field to the header of the column that holds it.
+type Mapping map[string]string
+
+// DetectFormat picks the format from a file name, falling back to CSV.
+func DetectFormat(filename string) Format {
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		return XLSX
+	}
+	return CSV
+}
+
+// ParseMapping reads a mapping written as "field=Column,field=Column".
+func ParseMapping(value string) (Mapping, error) {
+	mapping := Mapping{}
+	if strings.TrimSpace(value) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("mapping %q is not field=column", pair)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(field))] = strings.TrimSpace(column)
+	}
+	return mapping, mapping.check()
+}
+
+func (m Mapping) check() error {
+	for field := range m {
+		known := false
+		for _, f := range Fields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("unknown field %q, use one of %s", field, strings.Join(Fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Table is a header row and the data rows under it.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Read reads the first sheet of an XLSX file or a whole CSV file.
+func Read(r io.Reader, format Format) (Table, error) {
+	var records [][]string
+	var err error
+
+	switch format {
+	case XLSX:
+		var file *excelize.File
+		file, err = excelize.OpenReader(r)
+		if err != nil {
+			return Table{}, err
+		}
+		defer file.Close()
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return Table{}, errors.New("the workbook has no sheets")
+		}
+		records, err = file.GetRows(sheets[0])
+	default:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+	}
+	if err != nil {
+		return Table{}, err
+	}
+	if len(records) == 0 {
+		return Table{}, errors.New("the file is empty")
+	}
+
+	header := records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	return Table{Header: header, Rows: records[1:]}, nil
+}
+
+// columns resolves the mapping against the header. Fields without a mapping are read
+// from a column of the same name, ignoring case.
+func (t Table) columns(mapping Mapping) (map[string]int, error) {
+	index := map[string]int{}
+	for i, name := range t.Header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := map[string]int{}
+	for _, field := range Fields {
+		column, mapped := mapping[field]
+		if !mapped {
+			column = field
+		}
+		if i, ok := index[strings.ToLower(column)]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("column %q mapped to %s is not in the file", column, field)
+		}
+	}
+	return columns, nil
+}
+
+// Row is one data row turned into product fields. Only fields with a value are set, so
+// a row can be merged over an existing product.
+type Row struct {
+	Number   int /// The line in the file, counting the header as line 1
+	Product  models.Product
+	HasStock bool /// Whether the row sets the stock, which may legitimately be 0
+	Errors   []string
+}
+
+// Products maps every data row onto a product. Cells that cannot be parsed are reported on
+// the row instead of failing the whole file.
+func (t Table) Products(mapping Mapping) ([]Row, error) {
+	columns, err := t.columns(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(t.Rows))
+	for i, record := range t.Rows {
+		if blank(record) {
+			continue
+		}
+		row := Row{Number: i + 2}
+		cell := func(field string) string {
+			column, ok := columns[field]
+			if !ok || column >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[column])
+		}
+
+		product := &row.Product
+		product.Barcode = cell("barcode")
+		if value := cell("name"); value != "" {
+			product.Name = &value
+		}
+		if value := cell("description"); value != "" {
+			product.Description = &value
+		}
+		if value := cell("category"); value != "" {
+			category, err := types.ParseCategory(value)
+			if err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			} else {
+				number := int(category)
+				product.Category = &number
+			}
+		}
+		if value := cell("price"); value != "" {
+			price, err := types.NewMoney(value, cell("currency"))
+			if err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			} else {
+				product.Price = &price
+			}
+		}
+		if value := cell("stock"); value != "" {
+			stock, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("invalid stock %q", value))
+			} else {
+				product.Stock = uint(stock)
+				row.HasStock = true
+			}
+		}
+		product.BaseUnit = cell("baseunit")
+		if value := cell("location"); value != "" {
+			product.Location = &value
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func blank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
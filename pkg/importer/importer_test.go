@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+const sample = "\ufeffEAN,Product Name,description,category,Cost,stock\n" +
+	"4006381333931,Blue pen,Ballpoint pen,Stationery,1.20,50\n" +
+	",,,,,\n" +
+	"96385074,Cola,Soft drink,9,abc,x\n"
+
+func TestProductsFromCSV(t *testing.T) {
+	table, err := Read(strings.NewReader(sample), CSV)
+	assert.NoError(t, err)
+
+	mapping, err := ParseMapping("barcode=EAN, name=Product Name, price=Cost")
+	assert.NoError(t, err)
+
+	rows, err := table.Products(mapping)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	pen := rows[0]
+	assert.Equal(t, 2, pen.Number)
+	assert.Empty(t, pen.Errors)
+	assert.Equal(t, "4006381333931", pen.Product.Barcode)
+	assert.Equal(t, "Blue pen", *pen.Product.Name)
+	assert.Equal(t, 1, *pen.Product.Category)
+	assert.Equal(t, "1.20", pen.Product.Price.Amount.String())
+	assert.Equal(t, uint(50), pen.Product.Stock)
+	assert.True(t, pen.HasStock)
+
+	cola := rows[1]
+	assert.Equal(t, 4, cola.Number)
+	assert.Len(t, cola.Errors, 3)
+}
+
+func TestProductsFromXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]interface{}{"barcode", "name", "price"})
+	file.SetSheetRow("Sheet1", "A2", &[]interface{}{"4006381333931", "Blue pen", 1.2})
+
+	var buf bytes.Buffer
+	assert.NoError(t, file.Write(&buf))
+
+	table, err := Read(&buf, XLSX)
+	assert.NoError(t, err)
+
+	rows, err := table.Products(Mapping{})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Blue pen", *rows[0].Product.Name)
+	assert.False(t, rows[0].HasStock)
+}
+
+func TestMappingErrors(t *testing.T) {
+	_, err := ParseMapping("colour=Color")
+	assert.Error(t, err)
+
+	table, _ := Read(strings.NewReader(sample), CSV)
+	_, err = table.Products(Mapping{"name": "Title"})
+	assert.Error(t, err)
+}
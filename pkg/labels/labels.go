@@ -0,0 +1,158 @@
+// Package labels lays product labels out on sticker sheets and renders them as PDF.
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Deatsilence/go-stocket/pkg/barcode"
+	"github.com/go-pdf/fpdf"
+)
+
+// Layout describes a sticker sheet. All sizes are in millimetres.
+type Layout struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	PageWidth   float64 `json:"pagewidth"`
+	PageHeight  float64 `json:"pageheight"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"labelwidth"`
+	LabelHeight float64 `json:"labelheight"`
+	MarginTop   float64 `json:"margintop"`
+	MarginLeft  float64 `json:"marginleft"`
+	GapX        float64 `json:"gapx"`
+	GapY        float64 `json:"gapy"`
+}
+
+// PerPage is the number of labels on one sheet.
+func (l Layout) PerPage() int {
+	return l.Columns * l.Rows
+}
+
+// DefaultLayout is used when a request does not name a layout.
+const DefaultLayout = "a4-3x8"
+
+// Layouts are the sticker sheets labels can be printed on.
+var Layouts = map[string]Layout{
+	"a4-3x8": {
+		Name: "a4-3x8", Description: "A4, 3 x 8 labels of 70 x 37 mm",
+		PageWidth: 210, PageHeight: 297, Columns: 3, Rows: 8,
+		LabelWidth: 70, LabelHeight: 37, MarginTop: 0.5, MarginLeft: 0,
+	},
+	"a4-3x7": {
+		Name: "a4-3x7", Description: "A4, 3 x 7 labels of 63.5 x 38.1 mm",
+		PageWidth: 210, PageHeight: 297, Columns: 3, Rows: 7,
+		LabelWidth: 63.5, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 7.2, GapX: 2.5,
+	},
+	"a4-2x7": {
+		Name: "a4-2x7", Description: "A4, 2 x 7 labels of 99.1 x 38.1 mm",
+		PageWidth: 210, PageHeight: 297, Columns: 2, Rows: 7,
+		LabelWidth: 99.1, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 4.65, GapX: 2.5,
+	},
+	"letter-3x10": {
+		Name: "letter-3x10", Description: "US Letter, 3 x 10 labels of 66.7 x 25.4 mm",
+		PageWidth: 215.9, PageHeight: 279.4, Columns: 3, Rows: 10,
+		LabelWidth: 66.675, LabelHeight: 25.4, MarginTop: 12.7, MarginLeft: 4.7625, GapX: 3.175,
+	},
+}
+
+// LayoutNames lists the layouts in a stable order.
+func LayoutNames() []string {
+	names := make([]string, 0, len(Layouts))
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Label is the content of one sticker.
+type Label struct {
+	Name    string
+	Price   string
+	Barcode string
+}
+
+// Position returns the top left corner of the label at index i on its page.
+func (l Layout) Position(i int) (x float64, y float64) {
+	slot := i % l.PerPage()
+	column, row := slot%l.Columns, slot/l.Columns
+	x = l.MarginLeft + float64(column)*(l.LabelWidth+l.GapX)
+	y = l.MarginTop + float64(row)*(l.LabelHeight+l.GapY)
+	return x, y
+}
+
+// Render writes the labels to w as a PDF. Skip leaves the first positions of the first
+// sheet empty so that partly used sheets can be fed again.
+func Render(w io.Writer, layout Layout, items []Label, skip int) error {
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: layout.PageWidth, Ht: layout.PageHeight},
+	})
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	registered := map[string]bool{}
+	padding := 2.0
+
+	for i, item := range items {
+		index := i + skip
+		if index%layout.PerPage() == 0 || i == 0 {
+			pdf.AddPage()
+		}
+		x, y := layout.Position(index)
+		innerWidth := layout.LabelWidth - 2*padding
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetXY(x+padding, y+padding)
+		pdf.CellFormat(innerWidth, 4, fit(pdf, translate(item.Name), innerWidth), "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(x+padding, y+padding+4.5)
+		pdf.CellFormat(innerWidth, 4, translate(item.Price), "", 0, "L", false, 0, "")
+
+		if item.Barcode == "" {
+			continue
+		}
+		imageName := "barcode-" + item.Barcode
+		if !registered[imageName] {
+			symbol, err := barcode.Encode(item.Barcode)
+			if err != nil {
+				return fmt.Errorf("label %d: %w", i, err)
+			}
+			var buf bytes.Buffer
+			if err := symbol.WritePNG(&buf, barcode.RenderOptions{Scale: 2, Height: 40, Margin: 0, Text: true}); err != nil {
+				return err
+			}
+			pdf.RegisterImageOptionsReader(imageName, fpdf.ImageOptions{ImageType: "PNG"}, &buf)
+			registered[imageName] = true
+		}
+
+		top := y + padding + 10
+		height := layout.LabelHeight - 10 - 2*padding
+		if height > 0 {
+			pdf.ImageOptions(imageName, x+padding, top, innerWidth, height, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		}
+	}
+	if len(items) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
+
+// fit shortens text with an ellipsis until it fits the width. The text is already
+// translated to the single byte encoding of the core fonts.
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
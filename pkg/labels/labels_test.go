@@ -0,0 +1,30 @@
+package labels
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayoutsFitTheirPage(t *testing.T) {
+	for name, layout := range Layouts {
+		x, y := layout.Position(layout.PerPage() - 1)
+		assert.LessOrEqual(t, x+layout.LabelWidth, layout.PageWidth+0.01, name)
+		assert.LessOrEqual(t, y+layout.LabelHeight, layout.PageHeight+0.01, name)
+	}
+}
+
+func TestRender(t *testing.T) {
+	layout := Layouts[DefaultLayout]
+	items := make([]Label, 30)
+	for i := range items {
+		items[i] = Label{Name: "Ballpoint pen, blue, a very long name indeed", Price: "1.20 EUR", Barcode: "4006381333931"}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Render(&buf, layout, items, 2))
+	assert.True(t, strings.HasPrefix(buf.String(), "%PDF"))
+	assert.Equal(t, 2, strings.Count(buf.String(), "/Type /Page\n"))
+}
@@ -0,0 +1,29 @@
+// Package links picks products among the ones a product links to.
+package links
+
+import (
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+)
+
+// Substitutes keeps the substitutes of a product, and the product superseding it, that
+// have at least quantity in stock and can still be sold. A quantity of 0 counts as 1.
+func Substitutes(linked []models.LinkedProduct, quantity uint) []models.LinkedProduct {
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	substitutes := []models.LinkedProduct{}
+	for _, candidate := range linked {
+		if candidate.Type != string(types.LinkSubstitute) && candidate.Type != string(types.LinkSupersededBy) {
+			continue
+		}
+		if candidate.Status == string(types.ProductDraft) || candidate.Status == string(types.ProductArchived) {
+			continue
+		}
+		if candidate.Stock >= quantity {
+			substitutes = append(substitutes, candidate)
+		}
+	}
+	return substitutes
+}
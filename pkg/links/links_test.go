@@ -0,0 +1,44 @@
+package links
+
+import (
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubstitutes(t *testing.T) {
+	substitute := string(types.LinkSubstitute)
+
+	tests := []struct {
+		name     string
+		linked   models.LinkedProduct
+		quantity uint
+		kept     bool
+	}{
+		{"substitute", models.LinkedProduct{Type: substitute, Stock: 5}, 1, true},
+		{"superseded by", models.LinkedProduct{Type: string(types.LinkSupersededBy), Stock: 5}, 1, true},
+		{"accessory", models.LinkedProduct{Type: string(types.LinkAccessory), Stock: 5}, 1, false},
+		{"no status", models.LinkedProduct{Type: substitute, Status: "", Stock: 5}, 1, true},
+		{"active", models.LinkedProduct{Type: substitute, Status: string(types.ProductActive), Stock: 5}, 1, true},
+		{"discontinued", models.LinkedProduct{Type: substitute, Status: string(types.ProductDiscontinued), Stock: 5}, 1, true},
+		{"draft", models.LinkedProduct{Type: substitute, Status: string(types.ProductDraft), Stock: 5}, 1, false},
+		{"archived", models.LinkedProduct{Type: substitute, Status: string(types.ProductArchived), Stock: 5}, 1, false},
+		{"stock equal to quantity", models.LinkedProduct{Type: substitute, Stock: 3}, 3, true},
+		{"stock below quantity", models.LinkedProduct{Type: substitute, Stock: 2}, 3, false},
+		{"zero quantity in stock", models.LinkedProduct{Type: substitute, Stock: 1}, 0, true},
+		{"zero quantity out of stock", models.LinkedProduct{Type: substitute, Stock: 0}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Substitutes([]models.LinkedProduct{tt.linked}, tt.quantity)
+			if tt.kept {
+				assert.Equal(t, []models.LinkedProduct{tt.linked}, got)
+			} else {
+				assert.Empty(t, got)
+			}
+		})
+	}
+}
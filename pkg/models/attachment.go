@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Attachment is an image or document uploaded for a product. The files themselves are
+// kept in the attachment storage under Key and ThumbnailKey.
+type Attachment struct {
+	AttachmentID string    `json:"attachmentid"`
+	Kind         string    `json:"kind"` /// image or document
+	Name         string    `json:"name"` /// The name of the uploaded file
+	ContentType  string    `json:"contenttype"`
+	Size         int64     `json:"size"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailurl,omitempty"` /// Only images have a thumbnail
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	UploadedBy   string    `json:"uploadedby"`
+	UploadedAt   time.Time `json:"uploadedat"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttributeSchema lists the attributes the products of a category can have.
+type AttributeSchema struct {
+	ID         primitive.ObjectID    `bson:"_id,omitempty"`
+	Category   int                   `json:"category"`
+	Attributes []AttributeDefinition `json:"attributes" validate:"max=50,dive"`
+	UpdatedBy  string                `json:"updatedby"`
+	UpdatedAt  time.Time             `json:"updatedat"`
+}
+
+// AttributeDefinition describes one attribute, such as the voltage of an appliance or
+// the allergens of a food.
+type AttributeDefinition struct {
+	Name     string   `json:"name" validate:"required,max=30"`
+	Type     string   `json:"type" validate:"required,oneof=text number boolean enum list"`
+	Required bool     `json:"required"`
+	Values   []string `json:"values" validate:"omitempty,dive,min=1,max=50"` /// The allowed values of an enum, or of the items of a list
+	Unit     string   `json:"unit" validate:"omitempty,max=20"`              /// The unit of a number, such as V or months
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+}
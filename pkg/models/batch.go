@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/Deatsilence/go-stocket/types"
+)
+
+// BatchUpdate changes many products at once, either with a patch per product or with one
+// operation applied to every product matching a filter.
+type BatchUpdate struct {
+	Patches   []BatchPatch    `json:"patches" validate:"omitempty,max=1000,dive"`
+	Filter    *BatchFilter    `json:"filter"`
+	Operation *BatchOperation `json:"operation"`
+	Atomic    bool            `json:"atomic"` /// Apply everything in one transaction or nothing at all
+}
+
+// BatchPatch sets the price and stock of one product. Fields left out are kept.
+type BatchPatch struct {
+	ProductID string       `json:"productid" validate:"required"`
+	Price     *types.Money `json:"price"`
+	Stock     *uint        `json:"stock"`
+}
+
+// BatchFilter selects the products an operation is applied to.
+type BatchFilter struct {
+	ProductIDs []string             `json:"productids"`
+	Category   *types.CategoryTypes `json:"category"` /// A category number or name
+	Location   *string              `json:"location"`
+}
+
+// BatchOperation changes a price or stock by setting it, adding to it or, for prices,
+// scaling it by a percentage.
+type BatchOperation struct {
+	Field    string      `json:"field" validate:"required,oneof=price stock"`
+	Op       string      `json:"op" validate:"required,oneof=set add percent"`
+	Value    json.Number `json:"value" validate:"required"`
+	Currency string      `json:"currency" validate:"omitempty,iso4217"` /// The currency of a price that is set
+}
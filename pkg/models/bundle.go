@@ -0,0 +1,7 @@
+package models
+
+// BundleComponent is a product a bundle is made of and how many of it go into one bundle.
+type BundleComponent struct {
+	ProductID string `json:"productid" validate:"required"`
+	Quantity  uint   `json:"quantity" validate:"required,gt=0"` /// The quantity in the base unit of the component
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CountSession is a stocktake of the products in its scope. The expected quantities are
+// frozen when the session is opened.
+type CountSession struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       *string            `json:"name" validate:"required,min=2,max=50"`
+	Categories []int              `json:"categories"` /// Only products in these categories are counted
+	Locations  []string           `json:"locations"`  /// Only products at these locations are counted
+	Status     string             `json:"status"`     /// The state of the session (open, posted, cancelled)
+	Lines      []CountLine        `json:"lines"`
+	OpenedBy   string             `json:"openedby"`
+	OpenedAt   time.Time          `json:"openedat"`
+	PostedBy   string             `json:"postedby"`
+	PostedAt   time.Time          `json:"postedat"`
+	SessionID  string             `json:"sessionid"`
+}
+
+// CountLine is the expected and counted quantity of one product, in its base unit.
+type CountLine struct {
+	ProductID string    `json:"productid"`
+	Barcode   string    `json:"barcode"`
+	Name      string    `json:"name"`
+	Location  string    `json:"location"`
+	Expected  uint      `json:"expected"`
+	Counted   *uint     `json:"counted" bson:"counted,omitempty"` /// Nil until the product has been counted
+	Variance  *int      `json:"variance" bson:"-"`                /// Counted minus expected, filled in when the session is read
+	CountedBy string    `json:"countedby"`
+	CountedAt time.Time `json:"countedat"`
+}
+
+// CountEntry is a counted quantity submitted for a session, either for a product id or
+// for a scanned product or pack barcode.
+type CountEntry struct {
+	ProductID string `json:"productid"`
+	Barcode   string `json:"barcode"`
+	Unit      string `json:"unit"`
+	Quantity  *uint  `json:"quantity"`                                /// Defaults to 1, so a scan counts one unit
+	Mode      string `json:"mode" validate:"omitempty,oneof=add set"` /// Add to the count (default) or replace it
+}
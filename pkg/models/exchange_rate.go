@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExchangeRate is the number of base currency units one unit of Currency is worth.
+type ExchangeRate struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty"`
+	Currency  string               `json:"currency" validate:"required,iso4217"`
+	Rate      primitive.Decimal128 `json:"rate"`
+	UpdatedBy string               `json:"updatedby"`
+	UpdatedAt time.Time            `json:"updatedat"`
+}
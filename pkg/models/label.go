@@ -0,0 +1,13 @@
+package models
+
+// LabelRequest selects the products to print labels for and how many of each.
+type LabelRequest struct {
+	ProductIDs []string        `json:"productids"`                          /// Print these products
+	Category   *int            `json:"category"`                            /// Or every product in this category
+	Search     string          `json:"search"`                              /// Or every product whose name or barcode contains this text
+	Layout     string          `json:"layout"`                              /// The sticker sheet, a4-3x8 by default
+	Copies     uint            `json:"copies" validate:"max=5000"`          /// Labels per product, 1 by default
+	PerStock   bool            `json:"perstock"`                            /// One label per unit in stock instead of Copies
+	Quantities map[string]uint `json:"quantities" validate:"dive,max=5000"` /// Labels for single products, overriding the above
+	Skip       int             `json:"skip" validate:"min=0,max=100"`       /// Positions to leave empty on the first sheet
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceChange is an entry in the price timeline of a product. Applied changes keep the
+// price they replaced, scheduled ones become the current price at EffectiveAt.
+type PriceChange struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID     string             `json:"productid"`
+	OldPrice      *types.Money       `json:"oldprice"`
+	NewPrice      *types.Money       `json:"newprice" validate:"required"`
+	Status        string             `json:"status"`      /// The state of the change (scheduled, applied, cancelled)
+	ChangedBy     string             `json:"changedby"`   /// The user who made or scheduled the change
+	CreatedAt     time.Time          `json:"createdat"`   /// The time the change was made or scheduled
+	EffectiveAt   time.Time          `json:"effectiveat"` /// The time the price is (or was) in effect from
+	PriceChangeID string             `json:"pricechangeid"`
+}
@@ -3,18 +3,31 @@ package models
 import (
 	"time"
 
+	"github.com/Deatsilence/go-stocket/types"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Product struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	Barcode     string             `json:"barcode" validate:"required"`
-	Name        *string            `json:"name" validate:"required,min=2,max=50"`
-	Description *string            `json:"description" validate:"required,min=2,max=100"`
-	Category    *int               `json:"category" validate:"required"`
-	Price       float64            `json:"price" validate:"required"`
-	Stock       uint               `json:"stock" validate:"required"`
-	CreatedAt   time.Time          `json:"createdat"`
-	UpdatedAt   time.Time          `json:"updatedat"`
-	ProductID   string             `json:"productid"`
+	ID          primitive.ObjectID     `bson:"_id,omitempty"`
+	Barcode     string                 `json:"barcode" validate:"omitempty,barcode"`
+	Name        *string                `json:"name" validate:"required,min=2,max=50"`
+	Description *string                `json:"description" validate:"required,min=2,max=100"`
+	Category    *int                   `json:"category" validate:"required"`
+	Price       *types.Money           `json:"price" validate:"required"`
+	Prices      []types.Money          `json:"prices" validate:"omitempty,dive"`
+	Status      string                 `json:"status" validate:"omitempty,oneof=draft active discontinued archived"` /// The lifecycle status (draft, active, discontinued, archived)
+	Kind        string                 `json:"kind" validate:"omitempty,oneof=product bundle"`
+	Components  []BundleComponent      `json:"components" validate:"omitempty,max=50,dive"`  /// The products a bundle is made of
+	Stock       uint                   `json:"stock" validate:"required_unless=Kind bundle"` /// Computed from the components for a bundle
+	BaseUnit    string                 `json:"baseunit" validate:"omitempty,min=1,max=20"`
+	Units       []ProductUnit          `json:"units" validate:"omitempty,dive"`
+	Location    *string                `json:"location" validate:"omitempty,max=50"`
+	Attributes  map[string]interface{} `json:"attributes"` /// Values of the attributes defined for the category
+	Attachments []Attachment           `json:"attachments"`
+	Links       []ProductLink          `json:"links"`
+	Linked      []LinkedProduct        `json:"linked,omitempty" bson:"-"`      /// The linked products, filled in when asked for
+	Substitutes []LinkedProduct        `json:"substitutes,omitempty" bson:"-"` /// In-stock substitutes, filled in when the product is out of stock
+	CreatedAt   time.Time              `json:"createdat"`
+	UpdatedAt   time.Time              `json:"updatedat"`
+	ProductID   string                 `json:"productid"`
 }
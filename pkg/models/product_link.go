@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Deatsilence/go-stocket/types"
+)
+
+// ProductLink points from a product to a related one: a substitute that can replace it,
+// an accessory sold with it or the product that supersedes it.
+type ProductLink struct {
+	Type      string    `json:"type" validate:"required,oneof=substitute accessory supersededby"`
+	ProductID string    `json:"productid" validate:"required"` /// The linked product
+	CreatedBy string    `json:"createdby"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// LinkedProduct is a linked product as shown with the product it is linked from.
+type LinkedProduct struct {
+	Type      string       `json:"type"`
+	ProductID string       `json:"productid"`
+	Barcode   string       `json:"barcode"`
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	Stock     uint         `json:"stock"`
+	Price     *types.Money `json:"price"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a named set of product list parameters that can be run again by id.
+type SavedSearch struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Name          *string            `json:"name" validate:"required,min=2,max=50"`
+	Filters       map[string]string  `json:"filters"`                                          /// Product list filters such as category, q or minprice
+	Sort          string             `json:"sort"`                                             /// A product list sort such as "category,-price"
+	RecordPerPage int                `json:"recordPerPage" validate:"omitempty,min=1,max=100"` /// The page size used when the search is run
+	Shared        bool               `json:"shared"`                                           /// Whether other users can see and run the search
+	Digest        bool               `json:"digest"`                                           /// Email the owner when the products found change
+	OwnerID       string             `json:"ownerid"`
+	OwnerEmail    string             `json:"-"`
+	ResultIDs     []string           `json:"-"` /// The products found when the digest last ran
+	CheckedAt     time.Time          `json:"checkedat"`
+	CreatedAt     time.Time          `json:"createdat"`
+	UpdatedAt     time.Time          `json:"updatedat"`
+	SavedSearchID string             `json:"savedsearchid"`
+}
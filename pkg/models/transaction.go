@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/Deatsilence/go-stocket/types"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -11,7 +12,14 @@ type Transaction struct {
 	UserID        string             `json:"userid"`        /// The user who made the transaction
 	ProductID     string             `json:"productid"`     /// The product that the transaction is made
 	ProcessType   string             `json:"processtype"`   /// The type of the transaction (add, remove, update, delete)
-	Amount        uint               `json:"amount"`        /// The amount of the product
+	Amount        uint               `json:"amount"`        /// The amount of the product in its base unit
+	Direction     string             `json:"direction"`     /// The direction of a stock movement (in, out)
+	Unit          string             `json:"unit"`          /// The unit the movement was entered in
+	UnitQuantity  uint               `json:"unitquantity"`  /// The quantity in the entered unit
+	UnitCost      *types.Money       `json:"unitcost"`      /// The base currency cost of one base unit of an inbound movement
+	BatchID       string             `json:"batchid"`       /// The batch that posted the transaction together with others
+	FromStatus    string             `json:"fromstatus"`    /// The status a status change moved the product from
+	ToStatus      string             `json:"tostatus"`      /// The status a status change moved the product to
 	ProcessTime   time.Time          `json:"processtime"`   /// The time of the transaction
 	TransactionID string             `json:"transactionid"` /// The id of the transaction
 }
@@ -0,0 +1,18 @@
+package models
+
+import "github.com/Deatsilence/go-stocket/types"
+
+// ProductUnit is an alternate pack unit of a product, such as a box of 50 pieces.
+type ProductUnit struct {
+	Name    string `json:"name" validate:"required,min=1,max=20"`
+	Factor  uint   `json:"factor" validate:"required,gt=0"`      /// How many base units one pack contains
+	Barcode string `json:"barcode" validate:"omitempty,barcode"` /// Optional barcode printed on the pack
+}
+
+// StockMovement is the request body of a stock receive or issue.
+type StockMovement struct {
+	Quantity uint         `json:"quantity" validate:"required,gt=0"` /// The quantity in the given unit
+	Unit     string       `json:"unit"`                              /// The unit name, the base unit is used when empty
+	Barcode  string       `json:"barcode"`                           /// A scanned pack barcode, used when unit is empty
+	UnitCost *types.Money `json:"unitcost"`                          /// The cost of one entered unit, for receives
+}
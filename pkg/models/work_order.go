@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkOrder builds a quantity of a product in-house from components taken from stock.
+// All quantities are in base units.
+type WorkOrder struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty"`
+	ProductID   string               `json:"productid" validate:"required"`                    /// The product that is produced
+	Quantity    uint                 `json:"quantity" validate:"required,gt=0"`                /// The quantity that is produced
+	Components  []WorkOrderComponent `json:"components" validate:"required,min=1,max=50,dive"` /// The components that are consumed
+	Note        *string              `json:"note" validate:"omitempty,max=200"`
+	Status      string               `json:"status"`    /// The state of the work order (planned, inprogress, completed)
+	Method      string               `json:"method"`    /// The costing method the components were costed with
+	UnitCost    *types.Money         `json:"unitcost"`  /// The rolled up cost of one produced base unit, in the base currency
+	TotalCost   *types.Money         `json:"totalcost"` /// The cost of all consumed components, in the base currency
+	CreatedBy   string               `json:"createdby"`
+	CreatedAt   time.Time            `json:"createdat"`
+	StartedBy   string               `json:"startedby"`
+	StartedAt   time.Time            `json:"startedat"`
+	CompletedBy string               `json:"completedby"`
+	CompletedAt time.Time            `json:"completedat"`
+	UpdatedAt   time.Time            `json:"updatedat"`
+	WorkOrderID string               `json:"workorderid"`
+}
+
+// WorkOrderComponent is a component a work order consumes.
+type WorkOrderComponent struct {
+	ProductID string       `json:"productid" validate:"required"`
+	Quantity  uint         `json:"quantity" validate:"required,gt=0"`
+	Cost      *types.Money `json:"cost"` /// The cost of the consumed quantity, set when the work order is completed
+}
@@ -0,0 +1,270 @@
+package query
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Kind decides how the values of a field are read and which operators it supports.
+type Kind int
+
+const (
+	Text     Kind = iota /// ':' matches part of the text ignoring case, '=' the whole text
+	Keyword              /// Matched whole, ignoring case, like a currency code
+	Number               /// A whole number
+	Decimal              /// A decimal number such as a price amount
+	Category             /// A category by number or name
+	Date                 /// A YYYY-MM-DD date or an RFC 3339 time
+)
+
+// Field is a field a query may use.
+type Field struct {
+	Path   string /// The path of the field in the document
+	Legacy string /// A path the field was stored at before, matched as well
+	Kind   Kind
+}
+
+// ProductFields are the product fields a product query may use.
+var ProductFields = map[string]Field{
+	"name":        {Path: "name", Kind: Text},
+	"description": {Path: "description", Kind: Text},
+	"barcode":     {Path: "barcode", Kind: Text},
+	"category":    {Path: "category", Kind: Category},
+	"price":       {Path: "price.amount", Legacy: "price", Kind: Decimal},
+	"currency":    {Path: "price.currency", Kind: Keyword},
+	"stock":       {Path: "stock", Kind: Number},
+	"baseunit":    {Path: "baseunit", Kind: Keyword},
+	"location":    {Path: "location", Kind: Text},
+	"created":     {Path: "createdat", Kind: Date},
+	"updated":     {Path: "updatedat", Kind: Date},
+}
+
+// ProductDefaultField is searched by words written without a field.
+const ProductDefaultField = "name"
+
+// Compile turns a parsed query into a MongoDB filter. Only the given fields can be used
+// and every value is checked against the kind of its field, so the filter never holds
+// operators or patterns taken from the query.
+func Compile(node Node, fields map[string]Field, defaultField string) (bson.D, error) {
+	if node == nil {
+		return bson.D{}, nil
+	}
+	c := compiler{fields: fields, defaultField: defaultField}
+	return c.compile(node)
+}
+
+type compiler struct {
+	fields       map[string]Field
+	defaultField string
+}
+
+func (c compiler) compile(node Node) (bson.D, error) {
+	switch n := node.(type) {
+	case And:
+		return c.combine("$and", n.Nodes)
+	case Or:
+		return c.combine("$or", n.Nodes)
+	case Not:
+		inner, err := c.compile(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: "$nor", Value: bson.A{inner}}}, nil
+	case Comparison:
+		return c.comparison(n)
+	case Range:
+		return c.rangeFilter(n)
+	}
+	return nil, errorAt(1, "unsupported query")
+}
+
+func (c compiler) combine(operator string, nodes []Node) (bson.D, error) {
+	filters := bson.A{}
+	for _, node := range nodes {
+		filter, err := c.compile(node)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return bson.D{{Key: operator, Value: filters}}, nil
+}
+
+func (c compiler) field(name string, position int) (Field, error) {
+	if name == "" {
+		name = c.defaultField
+	}
+	field, ok := c.fields[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(c.fields))
+		for known := range c.fields {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return Field{}, errorAt(position, "unknown field %q, use one of %s", name, strings.Join(names, ", "))
+	}
+	return field, nil
+}
+
+var mongoOperators = map[string]string{"!=": "$ne", "<": "$lt", "<=": "$lte", ">": "$gt", ">=": "$gte"}
+
+func (c compiler) comparison(n Comparison) (bson.D, error) {
+	field, err := c.field(n.Field, n.Position)
+	if err != nil {
+		return nil, err
+	}
+	name := n.Field
+	if name == "" {
+		name = c.defaultField
+	}
+
+	switch field.Kind {
+	case Text, Keyword:
+		switch n.Operator {
+		case ":", "=", "!=":
+		default:
+			return nil, errorAt(n.Position, "%s can only be compared with :, = or !=", name)
+		}
+		pattern := "^" + regexp.QuoteMeta(n.Value.Text) + "$"
+		if field.Kind == Text && n.Operator == ":" {
+			pattern = regexp.QuoteMeta(n.Value.Text)
+		}
+		match := bson.D{{Key: "$regex", Value: pattern}, {Key: "$options", Value: "i"}}
+		if n.Operator == "!=" {
+			return bson.D{{Key: field.Path, Value: bson.D{{Key: "$not", Value: match}}}}, nil
+		}
+		return bson.D{{Key: field.Path, Value: match}}, nil
+
+	case Date:
+		day, exact, err := date(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		endOfDay := day
+		if !exact {
+			endOfDay = day.Add(24*time.Hour - time.Nanosecond)
+		}
+		// A plain date stands for the whole day.
+		switch n.Operator {
+		case ":", "=":
+			return bson.D{{Key: field.Path, Value: bson.D{{Key: "$gte", Value: day}, {Key: "$lte", Value: endOfDay}}}}, nil
+		case "!=":
+			return bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: field.Path, Value: bson.D{{Key: "$gte", Value: day}, {Key: "$lte", Value: endOfDay}}}}}}}, nil
+		case "<", ">=":
+			return bson.D{{Key: field.Path, Value: bson.D{{Key: mongoOperators[n.Operator], Value: day}}}}, nil
+		default:
+			return bson.D{{Key: field.Path, Value: bson.D{{Key: mongoOperators[n.Operator], Value: endOfDay}}}}, nil
+		}
+	}
+
+	value, err := c.value(field, name, n.Value)
+	if err != nil {
+		return nil, err
+	}
+	if field.Kind == Category && n.Operator != ":" && n.Operator != "=" && n.Operator != "!=" {
+		return nil, errorAt(n.Position, "%s can only be compared with :, = or !=", name)
+	}
+	if n.Operator == ":" || n.Operator == "=" {
+		return match(field, value), nil
+	}
+	// A document stores the field at only one of its paths, so the other one is missing
+	// and would always be unequal.
+	if n.Operator == "!=" && field.Legacy != "" {
+		return bson.D{{Key: "$nor", Value: bson.A{match(field, value)}}}, nil
+	}
+	return match(field, bson.D{{Key: mongoOperators[n.Operator], Value: value}}), nil
+}
+
+// match applies a condition to the path of a field and to its legacy path, if any.
+func match(field Field, condition interface{}) bson.D {
+	if field.Legacy == "" {
+		return bson.D{{Key: field.Path, Value: condition}}
+	}
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: field.Path, Value: condition}},
+		bson.D{{Key: field.Legacy, Value: condition}},
+	}}}
+}
+
+func (c compiler) rangeFilter(n Range) (bson.D, error) {
+	field, err := c.field(n.Field, n.Position)
+	if err != nil {
+		return nil, err
+	}
+	if field.Kind != Number && field.Kind != Decimal && field.Kind != Date {
+		return nil, errorAt(n.Position, "%s cannot be used with a range", n.Field)
+	}
+
+	bounds := bson.D{}
+	if n.From != nil {
+		from, err := c.bound(field, n.Field, *n.From, false)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, bson.E{Key: "$gte", Value: from})
+	}
+	if n.To != nil {
+		to, err := c.bound(field, n.Field, *n.To, true)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, bson.E{Key: "$lte", Value: to})
+	}
+	return match(field, bounds), nil
+}
+
+func (c compiler) bound(field Field, name string, value Value, end bool) (interface{}, error) {
+	if field.Kind != Date {
+		return c.value(field, name, value)
+	}
+	t, exact, err := date(value)
+	if err != nil {
+		return nil, err
+	}
+	if end && !exact {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func (c compiler) value(field Field, name string, value Value) (interface{}, error) {
+	switch field.Kind {
+	case Number:
+		number, err := strconv.ParseInt(value.Text, 10, 64)
+		if err != nil {
+			return nil, errorAt(value.Position, "%s needs a whole number, not %q", name, value.Text)
+		}
+		return number, nil
+	case Decimal:
+		money, err := types.NewMoney(value.Text, "")
+		if err != nil {
+			return nil, errorAt(value.Position, "%s needs a number, not %q", name, value.Text)
+		}
+		return money.Amount, nil
+	case Category:
+		category, err := types.ParseCategory(value.Text)
+		if err != nil {
+			return nil, errorAt(value.Position, "%v", err)
+		}
+		return int(category), nil
+	}
+	return value.Text, nil
+}
+
+// date reads a plain date or an exact time and reports which it was.
+func date(value Value) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value.Text); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse("2006-01-02", value.Text)
+	if err != nil {
+		return time.Time{}, false, errorAt(value.Position, "%q is not a date, use YYYY-MM-DD or a quoted RFC 3339 time", value.Text)
+	}
+	return t, false, nil
+}
@@ -0,0 +1,367 @@
+// Package query parses a small filter language such as
+//
+//	category:Food price<5 (stock=0 OR NOT location:"back room")
+//
+// and compiles it to a MongoDB filter over an allow-list of fields.
+//
+// A comparison is a field, an operator and a value. The operators are ':' (contains for
+// text, equals otherwise), '=', '!=', '<', '<=', '>' and '>='. A range is written as
+// price:2..5 and either end may be left out. Values with spaces or any of ( ) : = < > !
+// are quoted with double quotes. Terms next to each other must all match, OR matches
+// either side, NOT or a leading '-' negates a term, and parentheses group. A word without
+// a field searches the default field.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest query accepted.
+const MaxLength = 1000
+
+// MaxTerms is the largest number of comparisons a query may have.
+const MaxTerms = 50
+
+// SyntaxError is a problem with a query at a position, counted in characters from 1.
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Position, e.Message)
+}
+
+func errorAt(position int, format string, args ...interface{}) error {
+	return &SyntaxError{Position: position, Message: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenRange
+	tokenOpen
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEnd:
+		return "the end of the query"
+	case tokenString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+const special = `()":=<>!`
+
+// lex splits a query into tokens. A '-' right before a term is a NOT; anywhere else it
+// is part of a word, as in a date or a negative number.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		position := i + 1
+		previous := tokenEnd
+		if len(tokens) > 0 {
+			previous = tokens[len(tokens)-1].kind
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpen, "(", position})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenClose, ")", position})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, errorAt(position, "the quote is never closed")
+			}
+			tokens = append(tokens, token{tokenString, b.String(), position})
+		case r == '.' && i+1 < len(runes) && runes[i+1] == '.':
+			tokens = append(tokens, token{tokenRange, "..", position})
+			i += 2
+		case strings.ContainsRune(":=<>!", r):
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != ':' && r != '=' {
+				operator += "="
+			}
+			if operator == "!" {
+				return nil, errorAt(position, "'!' must be followed by '=', use NOT to negate a term")
+			}
+			tokens = append(tokens, token{tokenOperator, operator, position})
+			i += utf8.RuneCountInString(operator)
+		case r == '-' && previous != tokenOperator && previous != tokenRange:
+			tokens = append(tokens, token{tokenNot, "-", position})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(special, runes[i]) {
+				if runes[i] == '.' && i+1 < len(runes) && runes[i+1] == '.' {
+					break
+				}
+				i++
+			}
+			text := string(runes[start:i])
+			kind := tokenWord
+			switch text {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind, text, position})
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(runes) + 1}), nil
+}
+
+// Node is a parsed query.
+type Node interface {
+	node()
+}
+
+// And matches when all of its nodes match.
+type And struct{ Nodes []Node }
+
+// Or matches when any of its nodes matches.
+type Or struct{ Nodes []Node }
+
+// Not matches when its node does not.
+type Not struct{ Node Node }
+
+// Value is a value as written in the query.
+type Value struct {
+	Text     string
+	Quoted   bool
+	Position int
+}
+
+// Comparison compares a field with a value. A term without a field has an empty Field
+// and searches the default field.
+type Comparison struct {
+	Field    string
+	Operator string
+	Value    Value
+	Position int
+}
+
+// Range matches a field between two values, both included. Either end may be nil.
+type Range struct {
+	Field    string
+	From, To *Value
+	Position int
+}
+
+func (And) node()        {}
+func (Or) node()         {}
+func (Not) node()        {}
+func (Comparison) node() {}
+func (Range) node()      {}
+
+type parser struct {
+	tokens []token
+	next   int
+	terms  int
+}
+
+// Parse parses a query. An empty query parses to nil.
+func Parse(input string) (Node, error) {
+	if utf8.RuneCountInString(input) > MaxLength {
+		return nil, errorAt(MaxLength+1, "the query is longer than %d characters", MaxLength)
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEnd {
+		return nil, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEnd {
+		if t.kind == tokenClose {
+			return nil, errorAt(t.position, "')' has no matching '('")
+		}
+		return nil, errorAt(t.position, "unexpected %s", t.describe())
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.peek().kind == tokenOr {
+		p.take()
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, next)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	first, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for {
+		t := p.peek()
+		if t.kind == tokenAnd {
+			p.take()
+		} else if t.kind == tokenEnd || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		next, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, next)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.peek().kind == tokenNot {
+		p.take()
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.take()
+	switch t.kind {
+	case tokenOpen:
+		if p.peek().kind == tokenClose {
+			return nil, errorAt(p.peek().position, "the parentheses are empty")
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.kind != tokenClose {
+			return nil, errorAt(t.position, "'(' is never closed")
+		}
+		return node, nil
+	case tokenWord, tokenString:
+		p.terms++
+		if p.terms > MaxTerms {
+			return nil, errorAt(t.position, "the query has more than %d terms", MaxTerms)
+		}
+		if t.kind == tokenWord && p.peek().kind == tokenOperator {
+			return p.parseComparison(t)
+		}
+		return Comparison{Operator: ":", Value: Value{Text: t.text, Quoted: t.kind == tokenString, Position: t.position}, Position: t.position}, nil
+	case tokenEnd:
+		return nil, errorAt(t.position, "the query ends where a term was expected")
+	}
+	return nil, errorAt(t.position, "expected a term but found %s", t.describe())
+}
+
+func (p *parser) parseComparison(field token) (Node, error) {
+	operator := p.take()
+
+	value, ok := p.value()
+	if operator.text == ":" && p.peek().kind == tokenRange {
+		p.take()
+		to, hasTo := p.value()
+		if !ok && !hasTo {
+			return nil, errorAt(operator.position, "the range after %s: needs at least one end", field.text)
+		}
+		r := Range{Field: field.text, Position: field.position}
+		if ok {
+			r.From = &value
+		}
+		if hasTo {
+			r.To = &to
+		}
+		return r, nil
+	}
+	if !ok {
+		return nil, errorAt(p.peek().position, "expected a value after %s%s but found %s", field.text, operator.text, p.peek().describe())
+	}
+	return Comparison{Field: field.text, Operator: operator.text, Value: value, Position: field.position}, nil
+}
+
+func (p *parser) value() (Value, bool) {
+	t := p.peek()
+	if t.kind != tokenWord && t.kind != tokenString {
+		return Value{}, false
+	}
+	p.take()
+	return Value{Text: t.text, Quoted: t.kind == tokenString, Position: t.position}, true
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/types"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func compile(t *testing.T, input string) (bson.D, error) {
+	t.Helper()
+	node, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(node, ProductFields, ProductDefaultField)
+}
+
+func TestParse(t *testing.T) {
+	node, err := Parse(`category:Food price<5 stock=0 name:"cola zero"`)
+	assert.NoError(t, err)
+	and, ok := node.(And)
+	assert.True(t, ok)
+	assert.Len(t, and.Nodes, 4)
+	assert.Equal(t, Comparison{Field: "name", Operator: ":", Value: Value{Text: "cola zero", Quoted: true, Position: 36}, Position: 31}, and.Nodes[3])
+
+	node, err = Parse(`a OR b c`)
+	assert.NoError(t, err)
+	or, ok := node.(Or)
+	assert.True(t, ok)
+	assert.IsType(t, And{}, or.Nodes[1])
+
+	node, err = Parse(`-stock>-1`)
+	assert.NoError(t, err)
+	assert.Equal(t, Not{Node: Comparison{Field: "stock", Operator: ">", Value: Value{Text: "-1", Position: 8}, Position: 2}}, node)
+
+	node, err = Parse(`price:..5`)
+	assert.NoError(t, err)
+	assert.Equal(t, Range{Field: "price", To: &Value{Text: "5", Position: 9}, Position: 1}, node)
+
+	node, err = Parse("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, node)
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	cases := map[string]string{
+		`name:"cola`:        `query error at position 6: the quote is never closed`,
+		`price<`:            `query error at position 7: expected a value after price< but found the end of the query`,
+		`(stock=0`:          `query error at position 1: '(' is never closed`,
+		`stock=0)`:          `query error at position 8: ')' has no matching '('`,
+		`stock=0 OR`:        `query error at position 11: the query ends where a term was expected`,
+		`name!cola`:         `query error at position 5: '!' must be followed by '=', use NOT to negate a term`,
+		`colour:red`:        `query error at position 1: unknown field "colour", use one of barcode, baseunit, category, created, currency, description, location, name, price, stock, updated`,
+		`stock>many`:        `query error at position 7: stock needs a whole number, not "many"`,
+		`name>b`:            `query error at position 1: name can only be compared with :, = or !=`,
+		`category:Toys`:     `query error at position 10: unknown category "Toys"`,
+		`created>yesterday`: `query error at position 9: "yesterday" is not a date, use YYYY-MM-DD or a quoted RFC 3339 time`,
+		`name:a..b`:         `query error at position 1: name cannot be used with a range`,
+	}
+	for input, message := range cases {
+		_, err := compile(t, input)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), input) {
+			assert.Equal(t, message, err.Error(), input)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	filter, err := compile(t, `category:Food price<5 stock=0`)
+	assert.NoError(t, err)
+	assert.Equal(t, "$and", filter[0].Key)
+	parts := filter[0].Value.(bson.A)
+	assert.Equal(t, bson.D{{Key: "category", Value: 3}}, parts[0])
+	assert.Equal(t, "$or", parts[1].(bson.D)[0].Key)
+	assert.Equal(t, bson.D{{Key: "stock", Value: int64(0)}}, parts[2])
+
+	// Patterns and operators in values are matched literally.
+	filter, err = compile(t, `name:"a.*(" OR NOT barcode="$where"`)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "name", Value: bson.D{{Key: "$regex", Value: `a\.\*\(`}, {Key: "$options", Value: "i"}}}},
+		bson.D{{Key: "$nor", Value: bson.A{
+			bson.D{{Key: "barcode", Value: bson.D{{Key: "$regex", Value: `^\$where$`}, {Key: "$options", Value: "i"}}}},
+		}}},
+	}}}, filter)
+
+	filter, err = compile(t, `cola`)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "name", Value: bson.D{{Key: "$regex", Value: "cola"}, {Key: "$options", Value: "i"}}}}, filter)
+
+	// Prices saved before Money existed are plain doubles at the price path.
+	five, _ := types.NewMoney("5", "")
+	filter, err = compile(t, `price<5`)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "price.amount", Value: bson.D{{Key: "$lt", Value: five.Amount}}}},
+		bson.D{{Key: "price", Value: bson.D{{Key: "$lt", Value: five.Amount}}}},
+	}}}, filter)
+
+	filter, err = compile(t, `price!=5`)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "price.amount", Value: five.Amount}},
+		bson.D{{Key: "price", Value: five.Amount}},
+	}}}}}}, filter)
+
+	filter, err = compile(t, `stock:1..10`)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "stock", Value: bson.D{{Key: "$gte", Value: int64(1)}, {Key: "$lte", Value: int64(10)}}}}, filter)
+}
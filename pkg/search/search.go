@@ -0,0 +1,312 @@
+// Package search tokenizes search queries and scores, with tolerance for typos, how well
+// a text matches them. It also cuts highlighted snippets out of matching text and compares
+// the results of a saved search between runs.
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTerms is the number of terms of a query that are used, the rest is ignored.
+const MaxTerms = 10
+
+// Terms splits a query into lower case words, dropping repeats and any punctuation, so
+// that nothing in the query can act as an operator.
+func Terms(query string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, word := range words(query) {
+		term := strings.ToLower(word.text)
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == MaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+type word struct {
+	text       string
+	start, end int /// Byte offsets in the text
+}
+
+func words(text string) []word {
+	var result []word
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			result = append(result, word{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, word{text: text[start:], start: start, end: len(text)})
+	}
+	return result
+}
+
+// Distance is the number of insertions, deletions, substitutions and swaps of adjacent
+// letters that turn a into b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = minInt(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// MaxEdits is the number of typos tolerated in a term. Short terms have to be exact.
+func MaxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	}
+	return 2
+}
+
+// MatchWord scores how well a word matches a term, from 1 for the same word down to 0 for
+// no match. A word that starts with the term and a word within MaxEdits typos of it
+// score in between.
+func MatchWord(term string, word string) float64 {
+	word = strings.ToLower(word)
+	if word == term {
+		return 1
+	}
+	if len([]rune(term)) >= 2 && strings.HasPrefix(word, term) {
+		return 0.9
+	}
+
+	edits := MaxEdits(term)
+	if edits == 0 {
+		return 0
+	}
+	// Also compare with the start of a longer word so that typing the first part of it
+	// with a typo still matches.
+	candidates := []string{word}
+	if runes := []rune(word); len(runes) > len([]rune(term)) {
+		candidates = append(candidates, string(runes[:len([]rune(term))]))
+	}
+
+	best := 0.0
+	for i, candidate := range candidates {
+		d := Distance(term, candidate)
+		if d > edits {
+			continue
+		}
+		score := 0.8 * (1 - float64(d)/float64(len([]rune(term))))
+		if i > 0 {
+			score *= 0.9
+		}
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// Field is a text to search in, with a weight relative to the other fields.
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// Score scores the fields against the terms from 0 to 1. Every term contributes its best
+// match in any field, weighted by the field.
+func Score(terms []string, fields ...Field) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	maxWeight := 0.0
+	for _, field := range fields {
+		if field.Weight > maxWeight {
+			maxWeight = field.Weight
+		}
+	}
+	if maxWeight == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, field := range fields {
+			for _, w := range words(field.Text) {
+				if score := MatchWord(term, w.text) * field.Weight; score > best {
+					best = score
+				}
+			}
+		}
+		total += best / maxWeight
+	}
+	return total / float64(len(terms))
+}
+
+// Fragments returns the pairs of adjacent letters of the terms. A word with a typo
+// nearly always still shares one of them with the term, so they can be used to find
+// candidates for fuzzy matching.
+func Fragments(terms []string) []string {
+	var fragments []string
+	seen := map[string]bool{}
+	for _, term := range terms {
+		runes := []rune(term)
+		if len(runes) < 2 {
+			continue
+		}
+		for i := 0; i+2 <= len(runes); i++ {
+			fragment := string(runes[i : i+2])
+			if !seen[fragment] {
+				seen[fragment] = true
+				fragments = append(fragments, fragment)
+			}
+		}
+	}
+	sort.Strings(fragments)
+	return fragments
+}
+
+// Highlight escapes the text for HTML and wraps the words matching a term in <em> tags.
+// When width is above zero and the text is longer, only a window of about width bytes
+// around the first match is kept. It reports false when no word matched.
+func Highlight(text string, terms []string, width int) (string, bool) {
+	var matched []word
+	for _, w := range words(text) {
+		for _, term := range terms {
+			if MatchWord(term, w.text) > 0 {
+				matched = append(matched, w)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if width > 0 && len(text) > width {
+		start = snapStart(text, matched[0].start-width/3)
+		end = snapEnd(text, start+width)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	position := start
+	for _, w := range matched {
+		if w.start < start || w.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[position:w.start]))
+		b.WriteString("<em>" + html.EscapeString(text[w.start:w.end]) + "</em>")
+		position = w.end
+	}
+	b.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String()), true
+}
+
+// snapStart moves an offset forward to the start of a word.
+func snapStart(text string, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	for _, w := range words(text) {
+		if w.start >= offset {
+			return w.start
+		}
+	}
+	return runeStart(text, offset)
+}
+
+// snapEnd moves an offset back to the end of a word.
+func snapEnd(text string, offset int) int {
+	if offset >= len(text) {
+		return len(text)
+	}
+	end := 0
+	for _, w := range words(text) {
+		if w.end > offset {
+			break
+		}
+		end = w.end
+	}
+	if end == 0 {
+		return runeStart(text, offset)
+	}
+	return end
+}
+
+// Changes compares the product ids a saved search found on its last run with the ones it
+// finds now and returns those added and those removed, each in the order of its list.
+func Changes(previous []string, current []string) (added []string, removed []string) {
+	before := map[string]bool{}
+	for _, id := range previous {
+		before[id] = true
+	}
+	now := map[string]bool{}
+	for _, id := range current {
+		now[id] = true
+		if !before[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range previous {
+		if !now[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+// runeStart moves an offset back to the start of the rune it falls in.
+func runeStart(text string, offset int) int {
+	for offset > 0 && offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
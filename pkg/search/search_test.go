@@ -0,0 +1,74 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"blue", "pen", "0"}, Terms(`Blue (pen) -"pen" $0`))
+	assert.Empty(t, Terms(" .*( "))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance("pencil", "pencil"))
+	assert.Equal(t, 1, Distance("pencil", "pensil"))
+	assert.Equal(t, 1, Distance("pencil", "penicl"))
+	assert.Equal(t, 2, Distance("pencil", "pncl"))
+	assert.Equal(t, 3, Distance("", "çay"))
+}
+
+func TestMatchWord(t *testing.T) {
+	assert.Equal(t, 1.0, MatchWord("pencil", "Pencil"))
+	assert.Equal(t, 0.9, MatchWord("pen", "pencils"))
+	assert.Greater(t, MatchWord("pensil", "pencil"), 0.0)
+	assert.Greater(t, MatchWord("notebok", "notebooks"), 0.0)
+	assert.Equal(t, 0.0, MatchWord("pen", "pan"))
+	assert.Equal(t, 0.0, MatchWord("stapler", "pencil"))
+}
+
+func TestScore(t *testing.T) {
+	name := Field{Name: "name", Text: "Blue pencil", Weight: 10}
+	description := Field{Name: "description", Text: "Soft graphite", Weight: 1}
+
+	exact := Score([]string{"pencil"}, name, description)
+	typo := Score([]string{"pensil"}, name, description)
+	inDescription := Score([]string{"graphite"}, name, description)
+
+	assert.Equal(t, 1.0, exact)
+	assert.Greater(t, exact, typo)
+	assert.Greater(t, typo, inDescription)
+	assert.Equal(t, 0.5, Score([]string{"pencil", "stapler"}, name))
+}
+
+func TestHighlight(t *testing.T) {
+	text, ok := Highlight("Blue <b> pencil", []string{"pencil"}, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "Blue &lt;b&gt; <em>pencil</em>", text)
+
+	text, ok = Highlight("one two three four five six seven eight pencil nine ten eleven twelve", []string{"pencil"}, 30)
+	assert.True(t, ok)
+	assert.Equal(t, "…eight <em>pencil</em> nine ten eleven…", text)
+
+	_, ok = Highlight("stapler", []string{"pencil"}, 0)
+	assert.False(t, ok)
+}
+
+func TestFragments(t *testing.T) {
+	assert.Equal(t, []string{"en", "pe"}, Fragments([]string{"pen", "a"}))
+}
+
+func TestChanges(t *testing.T) {
+	added, removed := Changes([]string{"a", "b", "c"}, []string{"c", "d", "a", "e"})
+	assert.Equal(t, []string{"d", "e"}, added)
+	assert.Equal(t, []string{"b"}, removed)
+
+	added, removed = Changes(nil, []string{"a"})
+	assert.Equal(t, []string{"a"}, added)
+	assert.Empty(t, removed)
+
+	added, removed = Changes([]string{"a"}, []string{"a"})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
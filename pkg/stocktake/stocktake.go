@@ -0,0 +1,67 @@
+// Package stocktake works out the variances of a stock count and the corrections that
+// post them.
+package stocktake
+
+import (
+	"errors"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/units"
+	"github.com/Deatsilence/go-stocket/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrEntryWithoutProduct = errors.New("a count entry needs a productid or a barcode")
+
+// FillVariances sets the variance, counted minus expected, of every counted line.
+// Lines that have not been counted keep a nil variance.
+func FillVariances(lines []models.CountLine) {
+	for i := range lines {
+		line := &lines[i]
+		if line.Counted == nil {
+			line.Variance = nil
+			continue
+		}
+		variance := int(*line.Counted) - int(line.Expected)
+		line.Variance = &variance
+	}
+}
+
+// Correction is the stock movement that posts a variance: inbound for a surplus and
+// outbound for a shortfall. It reports false for a zero variance.
+func Correction(variance int) (types.DirectionTypes, uint, bool) {
+	switch {
+	case variance > 0:
+		return types.Inbound, uint(variance), true
+	case variance < 0:
+		return types.Outbound, uint(-variance), true
+	}
+	return "", 0, false
+}
+
+// EntryFilter finds the product of a count entry by its product id or, without one, by
+// a barcode of the product itself or of one of its pack units.
+func EntryFilter(entry models.CountEntry) (bson.M, error) {
+	if entry.ProductID != "" {
+		return bson.M{"productid": entry.ProductID}, nil
+	}
+	if entry.Barcode == "" {
+		return nil, ErrEntryWithoutProduct
+	}
+	return bson.M{"$or": []bson.M{{"barcode": entry.Barcode}, {"units.barcode": entry.Barcode}}}, nil
+}
+
+// EntryQuantity converts the quantity of a count entry for a product to its base unit.
+// An entry without a quantity counts one of its unit, so that a scan counts one pack.
+func EntryQuantity(product models.Product, entry models.CountEntry) (uint, error) {
+	unit, err := units.Resolve(product, models.StockMovement{Unit: entry.Unit, Barcode: entry.Barcode})
+	if err != nil {
+		return 0, err
+	}
+
+	var quantity uint = 1
+	if entry.Quantity != nil {
+		quantity = *entry.Quantity
+	}
+	return units.ToBase(unit, quantity)
+}
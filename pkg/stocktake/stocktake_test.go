@@ -0,0 +1,107 @@
+package stocktake
+
+import (
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/Deatsilence/go-stocket/pkg/units"
+	"github.com/Deatsilence/go-stocket/types"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func uintPtr(value uint) *uint {
+	return &value
+}
+
+func tea() models.Product {
+	return models.Product{
+		ProductID: "tea",
+		Barcode:   "8690000000011",
+		BaseUnit:  "piece",
+		Units: []models.ProductUnit{
+			{Name: "box", Factor: 12, Barcode: "8690000000028"},
+			{Name: "crate", Factor: 144},
+		},
+	}
+}
+
+func TestFillVariances(t *testing.T) {
+	lines := []models.CountLine{
+		{ProductID: "surplus", Expected: 5, Counted: uintPtr(8)},
+		{ProductID: "shortfall", Expected: 5, Counted: uintPtr(2)},
+		{ProductID: "exact", Expected: 5, Counted: uintPtr(5)},
+		{ProductID: "lost", Expected: 5, Counted: uintPtr(0)},
+		{ProductID: "uncounted", Expected: 5},
+	}
+	FillVariances(lines)
+
+	expected := []int{3, -3, 0, -5}
+	for i, variance := range expected {
+		if assert.NotNil(t, lines[i].Variance, lines[i].ProductID) {
+			assert.Equal(t, variance, *lines[i].Variance, lines[i].ProductID)
+		}
+	}
+	assert.Nil(t, lines[4].Variance)
+}
+
+func TestCorrection(t *testing.T) {
+	direction, amount, ok := Correction(3)
+	assert.True(t, ok)
+	assert.Equal(t, types.Inbound, direction)
+	assert.Equal(t, uint(3), amount)
+
+	direction, amount, ok = Correction(-7)
+	assert.True(t, ok)
+	assert.Equal(t, types.Outbound, direction)
+	assert.Equal(t, uint(7), amount)
+
+	_, _, ok = Correction(0)
+	assert.False(t, ok)
+}
+
+func TestEntryFilter(t *testing.T) {
+	filter, err := EntryFilter(models.CountEntry{ProductID: "tea", Barcode: "8690000000028"})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"productid": "tea"}, filter)
+
+	filter, err = EntryFilter(models.CountEntry{Barcode: "8690000000028"})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": []bson.M{{"barcode": "8690000000028"}, {"units.barcode": "8690000000028"}}}, filter)
+
+	_, err = EntryFilter(models.CountEntry{})
+	assert.ErrorIs(t, err, ErrEntryWithoutProduct)
+}
+
+func TestEntryQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    models.CountEntry
+		expected uint
+		fails    bool
+	}{
+		{name: "product id counts one piece", entry: models.CountEntry{ProductID: "tea"}, expected: 1},
+		{name: "product barcode scan", entry: models.CountEntry{Barcode: "8690000000011"}, expected: 1},
+		{name: "pack barcode scan counts a box", entry: models.CountEntry{Barcode: "8690000000028"}, expected: 12},
+		{name: "pack barcode with quantity", entry: models.CountEntry{Barcode: "8690000000028", Quantity: uintPtr(3)}, expected: 36},
+		{name: "unit by name", entry: models.CountEntry{ProductID: "tea", Unit: "crate", Quantity: uintPtr(2)}, expected: 288},
+		{name: "base unit by name", entry: models.CountEntry{ProductID: "tea", Unit: "piece", Quantity: uintPtr(7)}, expected: 7},
+		{name: "zero sets nothing", entry: models.CountEntry{ProductID: "tea", Quantity: uintPtr(0)}, expected: 0},
+		{name: "unknown unit", entry: models.CountEntry{ProductID: "tea", Unit: "pallet"}, fails: true},
+		{name: "foreign barcode", entry: models.CountEntry{Barcode: "4000000000006"}, fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quantity, err := EntryQuantity(tea(), test.entry)
+			if test.fails {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, quantity)
+		})
+	}
+
+	_, err := EntryQuantity(tea(), models.CountEntry{ProductID: "tea", Unit: "crate", Quantity: uintPtr(^uint(0) / 100)})
+	assert.ErrorIs(t, err, units.ErrQuantityTooLarge)
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	local, err := NewLocal(root)
+	assert.NoError(t, err)
+
+	assert.NoError(t, local.Save(ctx, "products/42/a/sheet.pdf", strings.NewReader("datasheet")))
+
+	file, err := local.Open(ctx, "products/42/a/sheet.pdf")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "datasheet", string(content))
+
+	assert.NoError(t, local.Delete(ctx, "products/42/a/sheet.pdf"))
+	_, err = local.Open(ctx, "products/42/a/sheet.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, local.Delete(ctx, "products/42/a/sheet.pdf"))
+
+	// Empty directories are cleaned up, the root is kept.
+	_, err = os.Stat(filepath.Join(root, "products"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(root)
+	assert.NoError(t, err)
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	local, _ := NewLocal(t.TempDir())
+	for _, key := range []string{"", "../secret", "/etc/passwd", "a/../../b", "a//b", `a\b`} {
+		assert.ErrorIs(t, local.Save(context.Background(), key, strings.NewReader("")), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,77 @@
+// Package thumbnail scales uploaded JPEG, PNG, GIF and WebP images down to thumbnails.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultSize is the longest side of a thumbnail in pixels.
+const DefaultSize = 256
+
+// MaxPixels is the largest image, in pixels, that is decoded. It keeps a small file that
+// claims huge dimensions from exhausting memory.
+const MaxPixels = 40_000_000
+
+var ErrTooLarge = errors.New("the image is too large")
+
+// Make decodes an image and scales it to fit in a square of size pixels, keeping its
+// aspect ratio. Images that already fit are not enlarged. It returns the decoded format.
+func Make(r io.Reader, size int) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("not a supported image: %w", err)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, maxInt(1, height*size/width)
+		} else {
+			width, height = maxInt(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst, format, nil
+}
+
+// Encode writes a thumbnail as JPEG when it was made from a JPEG and as PNG otherwise,
+// which keeps transparency. It returns the content type written.
+func Encode(w io.Writer, img image.Image, format string) (string, error) {
+	if format == "jpeg" {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return "image/png", png.Encode(w, img)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
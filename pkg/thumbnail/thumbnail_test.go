@@ -0,0 +1,44 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pngImage(t *testing.T, width, height int) *bytes.Buffer {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.Black)
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return &buf
+}
+
+func TestMake(t *testing.T) {
+	thumb, format, err := Make(pngImage(t, 1000, 500), DefaultSize)
+	assert.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+
+	thumb, _, err = Make(pngImage(t, 100, 400), DefaultSize)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 256), thumb.Bounds())
+
+	thumb, _, err = Make(pngImage(t, 20, 10), DefaultSize)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 10), thumb.Bounds())
+
+	var buf bytes.Buffer
+	contentType, err := Encode(&buf, thumb, format)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+}
+
+func TestMakeRejectsOtherFiles(t *testing.T) {
+	_, _, err := Make(bytes.NewReader([]byte("%PDF-1.4")), DefaultSize)
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Package units converts quantities between the pack units of a product and its base unit.
+package units
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+)
+
+var ErrQuantityTooLarge = errors.New("quantity is too large")
+
+// Resolve finds the unit of a stock movement by its name or, when no name is given, by a
+// scanned product or pack barcode. An empty movement resolves to the base unit.
+func Resolve(product models.Product, movement models.StockMovement) (models.ProductUnit, error) {
+	base := models.ProductUnit{Name: product.BaseUnit, Factor: 1, Barcode: product.Barcode}
+
+	if movement.Unit == "" && movement.Barcode == "" {
+		return base, nil
+	}
+	if movement.Unit == product.BaseUnit || (movement.Unit == "" && movement.Barcode == product.Barcode) {
+		return base, nil
+	}
+	for _, unit := range product.Units {
+		if movement.Unit != "" && unit.Name == movement.Unit {
+			return unit, nil
+		}
+		if movement.Unit == "" && unit.Barcode != "" && unit.Barcode == movement.Barcode {
+			return unit, nil
+		}
+	}
+	if movement.Unit != "" {
+		return models.ProductUnit{}, fmt.Errorf("unit %q is not defined for this product", movement.Unit)
+	}
+	return models.ProductUnit{}, fmt.Errorf("barcode %q does not belong to this product", movement.Barcode)
+}
+
+// ToBase converts a quantity in the given unit to the base unit of the product.
+func ToBase(unit models.ProductUnit, quantity uint) (uint, error) {
+	base := quantity * unit.Factor
+	if unit.Factor != 0 && base/unit.Factor != quantity {
+		return 0, ErrQuantityTooLarge
+	}
+	return base, nil
+}
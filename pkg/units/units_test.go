@@ -0,0 +1,36 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/Deatsilence/go-stocket/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	product := models.Product{
+		Barcode:  "8690000000011",
+		BaseUnit: "piece",
+		Units:    []models.ProductUnit{{Name: "box", Factor: 12, Barcode: "8690000000028"}},
+	}
+
+	unit, err := Resolve(product, models.StockMovement{})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ProductUnit{Name: "piece", Factor: 1, Barcode: "8690000000011"}, unit)
+
+	unit, err = Resolve(product, models.StockMovement{Barcode: "8690000000028"})
+	assert.NoError(t, err)
+	assert.Equal(t, "box", unit.Name)
+
+	_, err = Resolve(product, models.StockMovement{Unit: "crate"})
+	assert.Error(t, err)
+}
+
+func TestToBase(t *testing.T) {
+	base, err := ToBase(models.ProductUnit{Factor: 12}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(36), base)
+
+	_, err = ToBase(models.ProductUnit{Factor: 1 << 40}, 1<<40)
+	assert.ErrorIs(t, err, ErrQuantityTooLarge)
+}
@@ -0,0 +1,217 @@
+// Package valuation computes the value of stock and the cost of goods issued from a
+// product's stock movements with FIFO, LIFO or weighted-average costing.
+package valuation
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Method string
+
+const (
+	FIFO            Method = "fifo"
+	LIFO            Method = "lifo"
+	WeightedAverage Method = "average"
+)
+
+// ParseMethod reads a costing method name, defaulting to FIFO when empty.
+func ParseMethod(name string) (Method, error) {
+	switch Method(strings.ToLower(name)) {
+	case "", FIFO:
+		return FIFO, nil
+	case LIFO:
+		return LIFO, nil
+	case WeightedAverage, "weightedaverage", "wac":
+		return WeightedAverage, nil
+	}
+	return "", fmt.Errorf("unknown costing method %q", name)
+}
+
+// Movement is a single inbound or outbound stock movement. UnitCost is only read for
+// inbound movements; a nil cost counts as zero.
+type Movement struct {
+	Time     time.Time
+	Inbound  bool
+	Quantity uint
+	UnitCost *big.Rat
+}
+
+// Adjustment is the movement that takes the stock of a product from one quantity to
+// another when it is set directly, as a product update or import does. It reports
+// false when the stock did not change.
+func Adjustment(from uint, to uint) (Movement, bool) {
+	switch {
+	case to > from:
+		return Movement{Inbound: true, Quantity: to - from}, true
+	case to < from:
+		return Movement{Inbound: false, Quantity: from - to}, true
+	}
+	return Movement{}, false
+}
+
+// Result is the valuation of one product over a period.
+type Result struct {
+	OpeningQuantity  uint
+	OpeningValue     *big.Rat
+	ReceivedQuantity uint
+	ReceivedValue    *big.Rat
+	IssuedQuantity   uint
+	IssuedCost       *big.Rat /// The cost of goods issued in the period
+	ClosingQuantity  uint
+	ClosingValue     *big.Rat
+}
+
+type layer struct {
+	quantity uint
+	unitCost *big.Rat
+}
+
+// inventory holds the cost layers of a product. Weighted average keeps a single layer.
+type inventory struct {
+	method Method
+	layers []layer
+}
+
+func (inv *inventory) quantity() uint {
+	var total uint
+	for _, l := range inv.layers {
+		total += l.quantity
+	}
+	return total
+}
+
+func (inv *inventory) value() *big.Rat {
+	total := new(big.Rat)
+	for _, l := range inv.layers {
+		total.Add(total, lineValue(l.quantity, l.unitCost))
+	}
+	return total
+}
+
+func (inv *inventory) receive(quantity uint, unitCost *big.Rat) {
+	if unitCost == nil {
+		unitCost = new(big.Rat)
+	}
+	if inv.method != WeightedAverage || len(inv.layers) == 0 {
+		inv.layers = append(inv.layers, layer{quantity: quantity, unitCost: new(big.Rat).Set(unitCost)})
+		return
+	}
+
+	onHand := inv.quantity()
+	if onHand+quantity == 0 {
+		return
+	}
+	total := new(big.Rat).Add(inv.value(), lineValue(quantity, unitCost))
+	average := total.Quo(total, new(big.Rat).SetInt64(int64(onHand+quantity)))
+	inv.layers = []layer{{quantity: onHand + quantity, unitCost: average}}
+}
+
+// issue removes quantity from the layers and returns its cost. Issuing more than is on
+// hand costs the excess at the last known unit cost.
+func (inv *inventory) issue(quantity uint) *big.Rat {
+	cost := new(big.Rat)
+	lastCost := new(big.Rat)
+
+	for quantity > 0 && len(inv.layers) > 0 {
+		index := 0
+		if inv.method == LIFO {
+			index = len(inv.layers) - 1
+		}
+		l := &inv.layers[index]
+		lastCost = l.unitCost
+
+		taken := l.quantity
+		if quantity < taken {
+			taken = quantity
+		}
+		cost.Add(cost, lineValue(taken, l.unitCost))
+		l.quantity -= taken
+		quantity -= taken
+
+		if l.quantity == 0 {
+			inv.layers = append(inv.layers[:index], inv.layers[index+1:]...)
+		}
+	}
+	if quantity > 0 {
+		cost.Add(cost, lineValue(quantity, lastCost))
+	}
+	return cost
+}
+
+// Value replays the movements with the given method and values the period from..to.
+// Movements before from only build the opening layers; movements after to are ignored.
+// A zero to means no upper bound.
+func Value(movements []Movement, method Method, from time.Time, to time.Time) Result {
+	sorted := make([]Movement, len(movements))
+	copy(sorted, movements)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	inv := &inventory{method: method}
+	result := Result{
+		OpeningValue:  new(big.Rat),
+		ReceivedValue: new(big.Rat),
+		IssuedCost:    new(big.Rat),
+	}
+	opened := false
+
+	for _, movement := range sorted {
+		if !to.IsZero() && movement.Time.After(to) {
+			break
+		}
+		inPeriod := !movement.Time.Before(from)
+		if inPeriod && !opened {
+			result.OpeningQuantity, result.OpeningValue = inv.quantity(), inv.value()
+			opened = true
+		}
+
+		if movement.Inbound {
+			inv.receive(movement.Quantity, movement.UnitCost)
+			if inPeriod {
+				result.ReceivedQuantity += movement.Quantity
+				result.ReceivedValue.Add(result.ReceivedValue, lineValue(movement.Quantity, movement.UnitCost))
+			}
+			continue
+		}
+
+		cost := inv.issue(movement.Quantity)
+		if inPeriod {
+			result.IssuedQuantity += movement.Quantity
+			result.IssuedCost.Add(result.IssuedCost, cost)
+		}
+	}
+	if !opened {
+		result.OpeningQuantity, result.OpeningValue = inv.quantity(), inv.value()
+	}
+
+	result.ClosingQuantity, result.ClosingValue = inv.quantity(), inv.value()
+	return result
+}
+
+// IssueCost is the cost the next issue of quantity would have after the movements, for
+// example to cost the components a work order consumes.
+func IssueCost(movements []Movement, method Method, quantity uint) *big.Rat {
+	sorted := make([]Movement, len(movements))
+	copy(sorted, movements)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	inv := &inventory{method: method}
+	for _, movement := range sorted {
+		if movement.Inbound {
+			inv.receive(movement.Quantity, movement.UnitCost)
+		} else {
+			inv.issue(movement.Quantity)
+		}
+	}
+	return inv.issue(quantity)
+}
+
+func lineValue(quantity uint, unitCost *big.Rat) *big.Rat {
+	if unitCost == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Mul(new(big.Rat).SetInt64(int64(quantity)), unitCost)
+}
@@ -0,0 +1,100 @@
+package valuation
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var day = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(days int) time.Time {
+	return day.AddDate(0, 0, days)
+}
+
+func movements() []Movement {
+	return []Movement{
+		{Time: at(0), Inbound: true, Quantity: 10, UnitCost: big.NewRat(1, 1)},
+		{Time: at(1), Inbound: true, Quantity: 10, UnitCost: big.NewRat(2, 1)},
+		{Time: at(2), Inbound: false, Quantity: 15},
+	}
+}
+
+func TestValueFIFO(t *testing.T) {
+	result := Value(movements(), FIFO, time.Time{}, time.Time{})
+
+	assert.Equal(t, uint(15), result.IssuedQuantity)
+	assert.Equal(t, big.NewRat(20, 1), result.IssuedCost)
+	assert.Equal(t, uint(5), result.ClosingQuantity)
+	assert.Equal(t, big.NewRat(10, 1), result.ClosingValue)
+}
+
+func TestValueLIFO(t *testing.T) {
+	result := Value(movements(), LIFO, time.Time{}, time.Time{})
+
+	assert.Equal(t, big.NewRat(25, 1), result.IssuedCost)
+	assert.Equal(t, big.NewRat(5, 1), result.ClosingValue)
+}
+
+func TestValueWeightedAverage(t *testing.T) {
+	result := Value(movements(), WeightedAverage, time.Time{}, time.Time{})
+
+	assert.Equal(t, big.NewRat(45, 2), result.IssuedCost)
+	assert.Equal(t, big.NewRat(15, 2), result.ClosingValue)
+}
+
+func TestValuePeriod(t *testing.T) {
+	result := Value(movements(), FIFO, at(1), at(1))
+
+	assert.Equal(t, uint(10), result.OpeningQuantity)
+	assert.Equal(t, big.NewRat(10, 1), result.OpeningValue)
+	assert.Equal(t, uint(10), result.ReceivedQuantity)
+	assert.Equal(t, big.NewRat(20, 1), result.ReceivedValue)
+	assert.Equal(t, uint(0), result.IssuedQuantity)
+	assert.Equal(t, uint(20), result.ClosingQuantity)
+}
+
+func TestIssueCost(t *testing.T) {
+	assert.Equal(t, big.NewRat(6, 1), IssueCost(movements(), FIFO, 3))
+	assert.Equal(t, big.NewRat(3, 1), IssueCost(movements(), LIFO, 3))
+	assert.Equal(t, big.NewRat(9, 2), IssueCost(movements(), WeightedAverage, 3))
+	assert.Equal(t, big.NewRat(0, 1), IssueCost(nil, FIFO, 3))
+}
+
+func TestAdjustmentKeepsClosingQuantityOnStock(t *testing.T) {
+	// Added with 10, received 5, set to 4, issued 1 and set to 20.
+	history := []Movement{{Time: at(0), Inbound: true, Quantity: 10, UnitCost: big.NewRat(1, 1)}}
+	stock := uint(10)
+
+	set := func(days int, to uint) {
+		movement, ok := Adjustment(stock, to)
+		assert.True(t, ok)
+		movement.Time = at(days)
+		history = append(history, movement)
+		stock = to
+	}
+	history = append(history, Movement{Time: at(1), Inbound: true, Quantity: 5, UnitCost: big.NewRat(2, 1)})
+	stock += 5
+	set(2, 4)
+	history = append(history, Movement{Time: at(3), Inbound: false, Quantity: 1})
+	stock--
+	set(4, 20)
+
+	for _, method := range []Method{FIFO, LIFO, WeightedAverage} {
+		assert.Equal(t, stock, Value(history, method, time.Time{}, time.Time{}).ClosingQuantity, method)
+	}
+
+	_, ok := Adjustment(7, 7)
+	assert.False(t, ok)
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := ParseMethod("")
+	assert.NoError(t, err)
+	assert.Equal(t, FIFO, method)
+
+	_, err = ParseMethod("random")
+	assert.Error(t, err)
+}
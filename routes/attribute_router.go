@@ -0,0 +1,16 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AttributeRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.GET("/api/attributes", controller.GetAttributeSchemas())
+	incomingRoutes.GET("/api/attributes/:category", controller.GetAttributeSchema())
+	incomingRoutes.PUT("/api/attributes/:category", controller.SetAttributeSchema())
+	incomingRoutes.DELETE("/api/attributes/:category", controller.DeleteAttributeSchema())
+}
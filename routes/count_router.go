@@ -0,0 +1,18 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CountRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.POST("/api/counts/open", controller.OpenCountSession())
+	incomingRoutes.GET("/api/counts", controller.GetCountSessions())
+	incomingRoutes.GET("/api/counts/:sessionid", controller.GetCountSession())
+	incomingRoutes.POST("/api/counts/:sessionid/entries", controller.SubmitCounts())
+	incomingRoutes.POST("/api/counts/:sessionid/post", controller.PostCountSession())
+	incomingRoutes.DELETE("/api/counts/:sessionid", controller.CancelCountSession())
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ExchangeRateRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.GET("/api/exchangerates", controller.GetExchangeRates())
+	incomingRoutes.PUT("/api/exchangerates/:currency", controller.SetExchangeRate())
+	incomingRoutes.DELETE("/api/exchangerates/:currency", controller.DeleteExchangeRate())
+}
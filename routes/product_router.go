@@ -12,8 +12,32 @@ func ProductRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.POST("/api/products/add", controller.AddAProduct())
 	incomingRoutes.DELETE("/api/products/delete/:productid", controller.DeleteAProduct())
 	incomingRoutes.GET("/api/products", controller.GetProducts())
+	incomingRoutes.GET("/api/products/export", controller.ExportProducts())
 	incomingRoutes.GET("/api/products/:productid", controller.GetProduct())
 	incomingRoutes.GET("/api/products/search", controller.SearchByBarcodePrefix())
+	incomingRoutes.GET("/api/products/textsearch", controller.SearchProducts())
 	incomingRoutes.PUT("/api/products/update/:productid", controller.UpdateAProduct())
 	incomingRoutes.PATCH("/api/products/updatepartially/:productid", controller.UpdateSomePropertiesOfProduct())
+	incomingRoutes.POST("/api/products/receive/:productid", controller.ReceiveStock())
+	incomingRoutes.POST("/api/products/issue/:productid", controller.IssueStock())
+	incomingRoutes.POST("/api/products/batchupdate", controller.BatchUpdateProducts())
+	incomingRoutes.POST("/api/products/import", controller.ImportProducts())
+	incomingRoutes.GET("/api/products/labels/layouts", controller.GetLabelLayouts())
+	incomingRoutes.POST("/api/products/labels", controller.PrintLabels())
+	incomingRoutes.GET("/api/products/:productid/barcode.png", controller.GetProductBarcode("png"))
+	incomingRoutes.GET("/api/products/:productid/barcode.svg", controller.GetProductBarcode("svg"))
+	incomingRoutes.GET("/api/products/:productid/qr.png", controller.GetProductQRCode("png"))
+	incomingRoutes.GET("/api/products/:productid/qr.svg", controller.GetProductQRCode("svg"))
+	incomingRoutes.POST("/api/products/:productid/attachments", controller.UploadAttachment())
+	incomingRoutes.GET("/api/products/:productid/attachments/:attachmentid", controller.GetAttachment(false))
+	incomingRoutes.GET("/api/products/:productid/attachments/:attachmentid/thumbnail", controller.GetAttachment(true))
+	incomingRoutes.DELETE("/api/products/:productid/attachments/:attachmentid", controller.DeleteAttachment())
+	incomingRoutes.POST("/api/products/:productid/status", controller.ChangeProductStatus())
+	incomingRoutes.GET("/api/products/:productid/forecast", controller.GetProductForecast())
+	incomingRoutes.GET("/api/products/:productid/links", controller.GetProductLinks())
+	incomingRoutes.POST("/api/products/:productid/links", controller.AddProductLink())
+	incomingRoutes.DELETE("/api/products/:productid/links/:type/:linkedid", controller.RemoveProductLink())
+	incomingRoutes.GET("/api/products/:productid/prices", controller.GetPriceHistory())
+	incomingRoutes.POST("/api/products/:productid/prices", controller.SchedulePriceChange())
+	incomingRoutes.DELETE("/api/products/:productid/prices/:pricechangeid", controller.CancelPriceChange())
 }
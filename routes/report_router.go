@@ -0,0 +1,14 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReportRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.GET("/api/reports/valuation", controller.GetInventoryValuation())
+	incomingRoutes.GET("/api/reports/stockouts", controller.GetStockOutReport())
+}
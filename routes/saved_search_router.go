@@ -0,0 +1,18 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SavedSearchRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.POST("/api/searches", controller.CreateSavedSearch())
+	incomingRoutes.GET("/api/searches", controller.GetSavedSearches())
+	incomingRoutes.GET("/api/searches/:searchid", controller.GetSavedSearch())
+	incomingRoutes.PUT("/api/searches/:searchid", controller.UpdateSavedSearch())
+	incomingRoutes.DELETE("/api/searches/:searchid", controller.DeleteSavedSearch())
+	incomingRoutes.GET("/api/searches/:searchid/run", controller.RunSavedSearch())
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StatsRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.GET("/api/stats/overview", controller.GetStatsOverview())
+	incomingRoutes.GET("/api/stats/movements", controller.GetMovementAnalytics())
+}
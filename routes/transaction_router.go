@@ -0,0 +1,14 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TransactionRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.GET("/api/transactions", controller.GetTransactions())
+	incomingRoutes.GET("/api/transactions/export", controller.ExportTransactions())
+}
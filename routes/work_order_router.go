@@ -0,0 +1,19 @@
+package routes
+
+import (
+	controller "github.com/Deatsilence/go-stocket/pkg/controllers"
+	"github.com/Deatsilence/go-stocket/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WorkOrderRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.POST("/api/workorders", controller.CreateWorkOrder())
+	incomingRoutes.GET("/api/workorders", controller.GetWorkOrders())
+	incomingRoutes.GET("/api/workorders/:workorderid", controller.GetWorkOrder())
+	incomingRoutes.PUT("/api/workorders/:workorderid", controller.UpdateWorkOrder())
+	incomingRoutes.POST("/api/workorders/:workorderid/start", controller.StartWorkOrder())
+	incomingRoutes.POST("/api/workorders/:workorderid/complete", controller.CompleteWorkOrder())
+	incomingRoutes.DELETE("/api/workorders/:workorderid", controller.DeleteWorkOrder())
+}
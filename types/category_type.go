@@ -1,5 +1,12 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type CategoryTypes int
 
 const (
@@ -9,3 +16,49 @@ const (
 	Drinks
 	Other
 )
+
+var categoryNames = map[CategoryTypes]string{
+	Stationery:  "Stationery",
+	Electronics: "Electronics",
+	Food:        "Food",
+	Drinks:      "Drinks",
+	Other:       "Other",
+}
+
+func (c CategoryTypes) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// ParseCategory reads a category by its number or its name, ignoring case.
+func ParseCategory(value string) (CategoryTypes, error) {
+	value = strings.TrimSpace(value)
+	if number, err := strconv.Atoi(value); err == nil {
+		if _, ok := categoryNames[CategoryTypes(number)]; ok {
+			return CategoryTypes(number), nil
+		}
+		return 0, fmt.Errorf("unknown category %d", number)
+	}
+	for category, name := range categoryNames {
+		if strings.EqualFold(name, value) {
+			return category, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown category %q", value)
+}
+
+// UnmarshalJSON accepts a category as its number or its name.
+func (c *CategoryTypes) UnmarshalJSON(b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	category, err := ParseCategory(fmt.Sprint(value))
+	if err != nil {
+		return err
+	}
+	*c = category
+	return nil
+}
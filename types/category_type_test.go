@@ -0,0 +1,34 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCategory(t *testing.T) {
+	category, err := ParseCategory("drinks")
+	assert.NoError(t, err)
+	assert.Equal(t, Drinks, category)
+
+	category, err = ParseCategory("3")
+	assert.NoError(t, err)
+	assert.Equal(t, Food, category)
+
+	_, err = ParseCategory("Toys")
+	assert.Error(t, err)
+}
+
+func TestCategoryUnmarshalJSON(t *testing.T) {
+	var filter struct {
+		Category CategoryTypes `json:"category"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category": "Drinks"}`), &filter))
+	assert.Equal(t, Drinks, filter.Category)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"category": 2}`), &filter))
+	assert.Equal(t, Electronics, filter.Category)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"category": "Toys"}`), &filter))
+}
@@ -0,0 +1,9 @@
+package types
+
+type CountStatusTypes string
+
+const (
+	CountOpen      CountStatusTypes = "open"
+	CountPosted    CountStatusTypes = "posted"
+	CountCancelled CountStatusTypes = "cancelled"
+)
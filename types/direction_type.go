@@ -0,0 +1,8 @@
+package types
+
+type DirectionTypes string
+
+const (
+	Inbound  DirectionTypes = "in"
+	Outbound DirectionTypes = "out"
+)
@@ -0,0 +1,9 @@
+package types
+
+type LinkTypes string
+
+const (
+	LinkSubstitute   LinkTypes = "substitute"
+	LinkAccessory    LinkTypes = "accessory"
+	LinkSupersededBy LinkTypes = "supersededby"
+)
@@ -0,0 +1,210 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MoneyScale is the number of decimal places kept for computed amounts.
+const MoneyScale = 4
+
+var ErrCurrencyMismatch = errors.New("currencies do not match")
+
+// Money is an exact decimal amount in an ISO 4217 currency. It is stored as Decimal128
+// so that prices never go through a float.
+type Money struct {
+	Amount   primitive.Decimal128 `json:"amount"`
+	Currency string               `json:"currency" validate:"omitempty,iso4217"`
+}
+
+// NewMoney parses a decimal string such as "12.50" into Money.
+func NewMoney(amount string, currency string) (Money, error) {
+	d, err := primitive.ParseDecimal128(strings.TrimSpace(amount))
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if d.IsNaN() || d.IsInf() != 0 {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	return Money{Amount: d, Currency: strings.ToUpper(currency)}, nil
+}
+
+// MoneyFromRat rounds r half to even to the given number of decimal places.
+func MoneyFromRat(r *big.Rat, currency string, scale int) Money {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(factor))
+
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// Compare twice the remainder with the denominator to decide the rounding direction.
+	twice := new(big.Int).Abs(new(big.Int).Mul(rem, big.NewInt(2)))
+	switch twice.Cmp(scaled.Denom()) {
+	case 1:
+		quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+	case 0:
+		if quo.Bit(0) == 1 {
+			quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+		}
+	}
+
+	d, _ := primitive.ParseDecimal128FromBigInt(quo, -scale)
+	return Money{Amount: d, Currency: currency}
+}
+
+// Rat returns the amount as an exact rational number.
+func (m Money) Rat() *big.Rat {
+	bi, exp, err := m.Amount.BigInt()
+	if err != nil {
+		return new(big.Rat)
+	}
+	r := new(big.Rat).SetInt(bi)
+	factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(exp))), nil))
+	if exp < 0 {
+		return r.Quo(r, factor)
+	}
+	return r.Mul(r, factor)
+}
+
+// Add returns the sum of two amounts in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := new(big.Rat).Add(m.Rat(), other.Rat())
+	return MoneyFromRat(sum, m.Currency, maxInt(m.Scale(), other.Scale())), nil
+}
+
+// Mul multiplies the amount by a factor such as a quantity or an exchange rate.
+func (m Money) Mul(factor *big.Rat) Money {
+	product := new(big.Rat).Mul(m.Rat(), factor)
+	return MoneyFromRat(product, m.Currency, MoneyScale)
+}
+
+// Cmp compares two amounts in the same currency and returns -1, 0 or +1.
+func (m Money) Cmp(other Money) int {
+	return m.Rat().Cmp(other.Rat())
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Rat().Sign() < 0
+}
+
+// String formats the money as "12.50 USD".
+func (m Money) String() string {
+	return strings.TrimSpace(m.Amount.String() + " " + m.Currency)
+}
+
+// Scale is the number of decimal places the amount is written with.
+func (m Money) Scale() int {
+	_, exp, err := m.Amount.BigInt()
+	if err != nil || exp >= 0 {
+		return 0
+	}
+	return -exp
+}
+
+// UnmarshalJSON accepts {"amount": "12.50", "currency": "EUR"} as well as a bare number or
+// string, which is read as an amount without a currency.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	trimmed := strings.TrimSpace(string(b))
+	if trimmed == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		amount, err := rawAmount(b)
+		if err != nil {
+			return err
+		}
+		parsed, err := NewMoney(amount, "")
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	amount, err := rawAmount(raw.Amount)
+	if err != nil {
+		return err
+	}
+	parsed, err := NewMoney(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalBSONValue reads a money document and also accepts the plain double prices
+// stored before Money existed.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+
+	switch t {
+	case bsontype.Double:
+		f, ok := raw.DoubleOK()
+		if !ok {
+			return errors.New("invalid legacy price")
+		}
+		parsed, err := NewMoney(strconv.FormatFloat(f, 'f', -1, 64), "")
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case bsontype.EmbeddedDocument:
+		var doc struct {
+			Amount   primitive.Decimal128 `bson:"amount"`
+			Currency string               `bson:"currency"`
+		}
+		if err := raw.Unmarshal(&doc); err != nil {
+			return err
+		}
+		m.Amount = doc.Amount
+		m.Currency = doc.Currency
+		return nil
+	case bsontype.Null:
+		return nil
+	}
+	return fmt.Errorf("cannot decode %v into Money", t)
+}
+
+func rawAmount(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", errors.New("amount is required")
+	}
+	var number json.Number
+	if err := json.Unmarshal(b, &number); err != nil {
+		return "", fmt.Errorf("invalid amount %s", string(b))
+	}
+	return number.String(), nil
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	var m Money
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 0.1, "currency": "eur"}`), &m))
+	assert.Equal(t, "0.1 EUR", m.String())
+
+	assert.NoError(t, json.Unmarshal([]byte(`"19.99"`), &m))
+	assert.Equal(t, "19.99", m.Amount.String())
+	assert.Equal(t, "", m.Currency)
+
+	assert.NoError(t, json.Unmarshal([]byte(`0`), &m))
+	assert.Equal(t, 0, m.Rat().Sign())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "abc", "currency": "USD"}`), &m))
+}
+
+func TestNewMoney(t *testing.T) {
+	rate, err := NewMoney(" 1.0825 ", "eur")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0825 EUR", rate.String())
+	assert.Equal(t, 1, rate.Rat().Sign())
+
+	zero, err := NewMoney("0", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, zero.Rat().Sign())
+
+	negative, err := NewMoney("-2.5", "")
+	assert.NoError(t, err)
+	assert.True(t, negative.IsNegative())
+
+	for _, amount := range []string{"", "abc", "NaN", "Infinity"} {
+		_, err := NewMoney(amount, "USD")
+		assert.Error(t, err, amount)
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a, _ := NewMoney("0.1", "USD")
+	b, _ := NewMoney("0.2", "USD")
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.3", sum.Amount.String())
+
+	eur, _ := NewMoney("1", "EUR")
+	_, err = a.Add(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	price, _ := NewMoney("2.50", "USD")
+	assert.Equal(t, "7.5000", price.Mul(big.NewRat(3, 1)).Amount.String())
+	assert.Equal(t, "0.8333", price.Mul(big.NewRat(1, 3)).Amount.String())
+}
+
+func TestMoneyFromRatRoundsHalfToEven(t *testing.T) {
+	assert.Equal(t, "0.12", MoneyFromRat(big.NewRat(125, 1000), "USD", 2).Amount.String())
+	assert.Equal(t, "0.14", MoneyFromRat(big.NewRat(135, 1000), "USD", 2).Amount.String())
+	assert.Equal(t, "-0.14", MoneyFromRat(big.NewRat(-135, 1000), "USD", 2).Amount.String())
+}
+
+func TestMoneyBSONRoundTripAndLegacyDouble(t *testing.T) {
+	price, _ := NewMoney("12.50", "USD")
+
+	data, err := bson.Marshal(bson.M{"price": price})
+	assert.NoError(t, err)
+
+	var decoded struct{ Price Money }
+	assert.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, "12.50 USD", decoded.Price.String())
+
+	legacy, _ := bson.Marshal(bson.M{"price": 12.5})
+	assert.NoError(t, bson.Unmarshal(legacy, &decoded))
+	assert.Equal(t, "12.5", decoded.Price.Amount.String())
+}
@@ -0,0 +1,9 @@
+package types
+
+type PriceStatusTypes string
+
+const (
+	PriceScheduled PriceStatusTypes = "scheduled"
+	PriceApplied   PriceStatusTypes = "applied"
+	PriceCancelled PriceStatusTypes = "cancelled"
+)
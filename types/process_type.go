@@ -6,4 +6,10 @@ const (
 	Add ProcessTypes = iota
 	Update
 	Delete
+	Receive
+	Issue
+	CountCorrection
+	Consume
+	Produce
+	StatusChange
 )
@@ -0,0 +1,8 @@
+package types
+
+type ProductKinds string
+
+const (
+	StandardProduct ProductKinds = "product"
+	Bundle          ProductKinds = "bundle"
+)
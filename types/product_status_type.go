@@ -0,0 +1,51 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ProductStatusTypes string
+
+const (
+	ProductDraft        ProductStatusTypes = "draft"
+	ProductActive       ProductStatusTypes = "active"
+	ProductDiscontinued ProductStatusTypes = "discontinued"
+	ProductArchived     ProductStatusTypes = "archived"
+)
+
+// productStatusTransitions lists the statuses a product can move to from each status.
+// Archived is final.
+var productStatusTransitions = map[ProductStatusTypes][]ProductStatusTypes{
+	ProductDraft:        {ProductActive, ProductArchived},
+	ProductActive:       {ProductDiscontinued},
+	ProductDiscontinued: {ProductActive, ProductArchived},
+}
+
+// ParseProductStatus reads a product status, ignoring case. Products saved before
+// statuses existed have an empty status, which reads as active.
+func ParseProductStatus(value string) (ProductStatusTypes, error) {
+	status := ProductStatusTypes(strings.ToLower(strings.TrimSpace(value)))
+	switch status {
+	case "":
+		return ProductActive, nil
+	case ProductDraft, ProductActive, ProductDiscontinued, ProductArchived:
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown product status %q", value)
+}
+
+// CanTransition reports whether a product can move from one status to another.
+func (s ProductStatusTypes) CanTransition(to ProductStatusTypes) bool {
+	for _, allowed := range productStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions lists the statuses a product can move to.
+func (s ProductStatusTypes) Transitions() []ProductStatusTypes {
+	return append([]ProductStatusTypes{}, productStatusTransitions[s]...)
+}
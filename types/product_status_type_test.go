@@ -0,0 +1,32 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductStatus(t *testing.T) {
+	status, err := ParseProductStatus("")
+	assert.NoError(t, err)
+	assert.Equal(t, ProductActive, status)
+
+	status, err = ParseProductStatus("Discontinued")
+	assert.NoError(t, err)
+	assert.Equal(t, ProductDiscontinued, status)
+
+	_, err = ParseProductStatus("deleted")
+	assert.Error(t, err)
+}
+
+func TestProductStatusTransitions(t *testing.T) {
+	assert.True(t, ProductDraft.CanTransition(ProductActive))
+	assert.True(t, ProductActive.CanTransition(ProductDiscontinued))
+	assert.True(t, ProductDiscontinued.CanTransition(ProductActive))
+	assert.True(t, ProductDiscontinued.CanTransition(ProductArchived))
+
+	assert.False(t, ProductActive.CanTransition(ProductDraft))
+	assert.False(t, ProductActive.CanTransition(ProductArchived))
+	assert.False(t, ProductActive.CanTransition(ProductActive))
+	assert.Empty(t, ProductArchived.Transitions())
+}
@@ -0,0 +1,9 @@
+package types
+
+type WorkOrderStatusTypes string
+
+const (
+	WorkOrderPlanned    WorkOrderStatusTypes = "planned"
+	WorkOrderInProgress WorkOrderStatusTypes = "inprogress"
+	WorkOrderCompleted  WorkOrderStatusTypes = "completed"
+)